@@ -10,6 +10,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"runtime"
 	"github.com/genetic-algorithms/mendel-go/utils"
@@ -19,6 +20,10 @@ import (
 
 const DATA_FILE_PATH_DEFAULT = "./user/output"
 
+// MAX_PEDIGREE_POP_SIZE is the largest tribe the pedigree and inbreeding files can be output for, because the kinship matrix of a generation
+// uses memory proportional to the square of its size (about 50 MB at this size, and the parents' and children's matrices are both kept).
+const MAX_PEDIGREE_POP_SIZE = 5000
+
 // Config is the struct that gets filled in by TOML automatically from the input file.
 type Config struct {
 	Basic struct {
@@ -29,7 +34,11 @@ type Config struct {
 	}  `toml:"basic"`
 	Mutations struct {
 		Mutn_rate float64  `toml:"mutn_rate"`
+		Paternal_mutn_rate float64  `toml:"paternal_mutn_rate"`
+		Maternal_mutn_rate float64  `toml:"maternal_mutn_rate"`
 		Mutn_rate_model string  `toml:"mutn_rate_model"`	// toml does not know how to handle user-defined types like MutationRateModelType
+		Mutn_rate_dispersion float64  `toml:"mutn_rate_dispersion"`
+		Mutn_rate_heterogeneity float64  `toml:"mutn_rate_heterogeneity"`
 		Frac_fav_mutn float64  `toml:"frac_fav_mutn"`
 		Fraction_neutral float64  `toml:"fraction_neutral"`
 		Genome_size float64  `toml:"genome_size"`
@@ -39,16 +48,37 @@ type Config struct {
 		High_impact_mutn_fraction float64  `toml:"high_impact_mutn_fraction"`
 		High_impact_mutn_threshold float64  `toml:"high_impact_mutn_threshold"`
 		Max_fav_fitness_gain float64  `toml:"max_fav_fitness_gain"`
+		Dfe_mean_del float64  `toml:"dfe_mean_del"`
+		Dfe_mean_fav float64  `toml:"dfe_mean_fav"`
+		Dfe_gamma_shape float64  `toml:"dfe_gamma_shape"`
+		Dfe_lognormal_sigma float64  `toml:"dfe_lognormal_sigma"`
+		Dfe_mixture string  `toml:"dfe_mixture"`
+		Dfe_empirical_file string  `toml:"dfe_empirical_file"`
 		Fraction_recessive float64  `toml:"fraction_recessive"`
 		Recessive_hetero_expression float64  `toml:"recessive_hetero_expression"`
 		Dominant_hetero_expression float64  `toml:"dominant_hetero_expression"`
+		Zygosity_fitness bool  `toml:"zygosity_fitness"`
+		Dominance_model string  `toml:"dominance_model"`
+		Dominance_hs_k float64  `toml:"dominance_hs_k"`
+		Fraction_lethal float64  `toml:"fraction_lethal"`
+		Fraction_sterile float64  `toml:"fraction_sterile"`
+		Fraction_recessive_lethal float64  `toml:"fraction_recessive_lethal"`
+		Fraction_trait_mutn float64  `toml:"fraction_trait_mutn"`
+		Trait_mutn_sd float64  `toml:"trait_mutn_sd"`
+		Num_traits uint32  `toml:"num_traits"`
+		Trait_correlation float64  `toml:"trait_correlation"`
+		Fraction_mutator float64  `toml:"fraction_mutator"`
+		Mutator_effect_sd float64  `toml:"mutator_effect_sd"`
+		Mutator_max_factor float64  `toml:"mutator_max_factor"`
+		Fraction_local_adaptation float64  `toml:"fraction_local_adaptation"`
+		Local_adaptation_cost float64  `toml:"local_adaptation_cost"`
 		Multiplicative_weighting float64  `toml:"multiplicative_weighting"`
 		Synergistic_epistasis bool  `toml:"synergistic_epistasis"`
 		Se_nonlinked_scaling float64  `toml:"se_nonlinked_scaling"`
 		Se_linked_scaling float64  `toml:"se_linked_scaling"`
 		Upload_mutations bool  `toml:"upload_mutations"`
 		Allow_back_mutn bool  `toml:"allow_back_mutn"`
-		Polygenic_beneficials bool  `polygenic_beneficials`
+		Polygenic_beneficials bool  `toml:"polygenic_beneficials"`
 		Polygenic_init string  `toml:"polygenic_init"`
 		Polygenic_target string  `toml:"polygenic_target"`
 		Polygenic_effect float64  `toml:"polygenic_effect"`
@@ -60,6 +90,16 @@ type Config struct {
 		Heritability float64  `toml:"heritability"`
 		Non_scaling_noise float64  `toml:"non_scaling_noise"`
 		Partial_truncation_value float64  `toml:"partial_truncation_value"`
+		Environment_model string  `toml:"environment_model"`
+		Selection_width float64  `toml:"selection_width"`
+		Optimum_shift float64  `toml:"optimum_shift"`
+		Optimum_period uint32  `toml:"optimum_period"`
+		Frequency_dependent_model string  `toml:"frequency_dependent_model"`
+		Frequency_dependent_mutations string  `toml:"frequency_dependent_mutations"`
+		Frequency_dependent_strength float64  `toml:"frequency_dependent_strength"`
+		Frequency_dependent_target float64  `toml:"frequency_dependent_target"`
+		Maternal_effect_weight float64  `toml:"maternal_effect_weight"`
+		Maternal_effect_fitness string  `toml:"maternal_effect_fitness"`
 	}  `toml:"selection"`
 	Population struct {
 		Reproductive_rate float64  `toml:"reproductive_rate"`
@@ -69,12 +109,15 @@ type Config struct {
 		Crossover_model string  `toml:"crossover_model"`
 		Mean_num_crossovers uint32  `toml:"mean_num_crossovers"`
 		Haploid_chromosome_number uint32  `toml:"haploid_chromosome_number"`
+		Ploidy uint32  `toml:"ploidy"`
+		Haploid_fusion_rate float64  `toml:"haploid_fusion_rate"`
 		Num_linkage_subunits uint32  `toml:"num_linkage_subunits"`
 		Num_contrasting_alleles uint32  `toml:"num_contrasting_alleles"`
 		Initial_allele_fitness_model string  `toml:"initial_allele_fitness_model"`
 		Initial_alleles_pop_frac float64  `toml:"initial_alleles_pop_frac"`
 		Initial_alleles_frequencies string  `toml:"initial_alleles_frequencies"`
 		Max_total_fitness_increase float64  `toml:"max_total_fitness_increase"`
+		Initial_variation_model string  `toml:"initial_variation_model"`
 		Pop_growth_model string  `toml:"pop_growth_model"`
 		Pop_growth_rate float64  `toml:"pop_growth_rate"`
 		Pop_growth_rate2 float64  `toml:"pop_growth_rate2"`
@@ -84,6 +127,17 @@ type Config struct {
 		Bottleneck_generation uint32  `toml:"bottleneck_generation"`
 		Bottleneck_pop_size uint32  `toml:"bottleneck_pop_size"`
 		Num_bottleneck_generations uint32  `toml:"num_bottleneck_generations"`
+		Pop_size_trajectory string  `toml:"pop_size_trajectory"`
+		Pop_size_trajectory_interpolation string  `toml:"pop_size_trajectory_interpolation"`
+		Catastrophe_rate float64  `toml:"catastrophe_rate"`
+		Catastrophe_model string  `toml:"catastrophe_model"`
+		Catastrophe_max_cull float64  `toml:"catastrophe_max_cull"`
+		Catastrophe_max_penalty float64  `toml:"catastrophe_max_penalty"`
+		Catastrophe_penalty_gens uint32  `toml:"catastrophe_penalty_gens"`
+		Initial_inversions string  `toml:"initial_inversions"`
+		Inversion_mutn_rate float64  `toml:"inversion_mutn_rate"`
+		Max_inversion_length uint32  `toml:"max_inversion_length"`
+		Imprinted_regions string  `toml:"imprinted_regions"`
 	}  `toml:"population"`
 	Tribes struct {
 		Num_tribes uint32  `toml:"num_tribes"`
@@ -96,7 +150,26 @@ type Config struct {
 		Tc_scaling_factor float64  `toml:"tc_scaling_factor"`
 		Group_heritability float64  `toml:"group_heritability"`
 		Social_bonus_factor float64  `toml:"social_bonus_factor"`
+		Demographic_events string  `toml:"demographic_events"`
+		Recolonization_delay uint32  `toml:"recolonization_delay"`
+		Num_recolonizers uint32  `toml:"num_recolonizers"`
+		Recolonization_weighting string  `toml:"recolonization_weighting"`
+		Lattice_model string  `toml:"lattice_model"`
+		Lattice_width uint32  `toml:"lattice_width"`
+		Lattice_wrap bool  `toml:"lattice_wrap"`
+		Dispersal_rate float64  `toml:"dispersal_rate"`
+		Dispersal_kernel string  `toml:"dispersal_kernel"`
+		Dispersal_scale float64  `toml:"dispersal_scale"`
+		Deme_gradients string  `toml:"deme_gradients"`
 	}  `toml:"tribes"`
+	Organelle struct {
+		Organelle_model string  `toml:"organelle_model"`
+		Organelle_num_linkage_subunits uint32  `toml:"organelle_num_linkage_subunits"`
+		Organelle_mutn_rate float64  `toml:"organelle_mutn_rate"`
+		Organelle_frac_fav_mutn float64  `toml:"organelle_frac_fav_mutn"`
+		Organelle_fraction_neutral float64  `toml:"organelle_fraction_neutral"`
+		Organelle_fitness_effect_scale float64  `toml:"organelle_fitness_effect_scale"`
+	}  `toml:"organelle"`
 	Computation struct {
 		Tracking_threshold float32  `toml:"tracking_threshold"`
 		Track_neutrals bool  `toml:"track_neutrals"`
@@ -105,6 +178,10 @@ type Config struct {
 		Data_file_path string  `toml:"data_file_path"`
 		Files_to_output string  `toml:"files_to_output"`
 		Plot_allele_gens uint32  `toml:"plot_allele_gens"`
+		Pedigree_first_gen uint32  `toml:"pedigree_first_gen"`
+		Pedigree_last_gen uint32  `toml:"pedigree_last_gen"`
+		Tree_sequence_simplify_interval uint32  `toml:"tree_sequence_simplify_interval"`
+		Fst_gens uint32  `toml:"fst_gens"`
 		Omit_first_allele_bin bool  `toml:"omit_first_allele_bin"`
 		//Restart_case bool  `toml:"restart_case"`
 		//Restart_dump_number uint32  `toml:"restart_dump_number"`
@@ -134,14 +211,21 @@ type ComputedValues struct {
 	Gamma_fav float64
 	Del_scale float64		// not sure if i really need these
 	Fav_scale float64
+	Trait_cholesky [][]float64		// lower triangular cholesky factor of the correlation matrix of the effects of a trait mutation on the traits
 }
 
 var Computed *ComputedValues
 
+var userMetaData toml.MetaData		// the keys that were set in the user's config file (as opposed to only in the defaults file)
+
+// isUserSet returns true if the specified key (e.g. "mutations", "fraction_recessive") was explicitly set in the user's config file
+func isUserSet(key ...string) bool { return userMetaData.IsDefined(key...) }
+
 // ReadFromFile reads the specified input file and parses all of the values into the Config struct.
 // This is also the factory method for the Config class and will store the created instance in this packages Cfg var.
 func ReadFromFile(filename string) error {
 	Cfg = &Config{} 		// create and set the singleton config
+	userMetaData = toml.MetaData{}
 
 	// 1st read defaults and then apply the specified config file values on top of that
 	defaultFile := FindDefaultFile()
@@ -150,7 +234,8 @@ func ReadFromFile(filename string) error {
 	if _, err := toml.DecodeFile(defaultFile, Cfg); err != nil { return err }
 	if filename != defaultFile {
 		log.Printf("Using config file %v\n", filename) 	// can not use verbosity here because we have not read the config file yet
-		if _, err := toml.DecodeFile(filename, Cfg); err != nil { return err }
+		var err error
+		if userMetaData, err = toml.DecodeFile(filename, Cfg); err != nil { return err }
 	}
 
 	// Do this before validate, because we need to know what output files have been requested for some of the validation testing
@@ -182,15 +267,135 @@ func (c *Config) validateAndAdjust() error {
 	if c.Computation.Tracking_threshold >= 1.0 && (FMgr.IsDir(ALLELE_BINS_DIRECTORY) || FMgr.IsDir(NORMALIZED_ALLELE_BINS_DIRECTORY) || FMgr.IsDir(DISTRIBUTION_DEL_DIRECTORY) || FMgr.IsDir(DISTRIBUTION_FAV_DIRECTORY)) {
 		return errors.New(ALLELE_BINS_DIRECTORY+", "+NORMALIZED_ALLELE_BINS_DIRECTORY+", "+DISTRIBUTION_DEL_DIRECTORY+", or "+DISTRIBUTION_FAV_DIRECTORY+" file output was requested, but no alleles can be plotted when tracking_threshold >= 1.0")
 	}
-	if !FMgr.IsDir(ALLELE_BINS_DIRECTORY) && !FMgr.IsDir(NORMALIZED_ALLELE_BINS_DIRECTORY) && !FMgr.IsDir(DISTRIBUTION_DEL_DIRECTORY) && !FMgr.IsDir(DISTRIBUTION_FAV_DIRECTORY) {
+	if c.Mutations.Zygosity_fitness && c.Mutations.Multiplicative_weighting != 0.0 { return errors.New("zygosity_fitness can not be used with multiplicative_weighting") }
+	if c.Mutations.Zygosity_fitness && c.Computation.Tracking_threshold != 0.0 { return errors.New("zygosity_fitness can not be used with a non-zero tracking_threshold, because only tracked mutations can be found to be homozygous") }
+	if c.Population.Ploidy > 2 && c.Computation.Tracking_threshold != 0.0 { return errors.New("ploidy > 2 can not be used with a non-zero tracking_threshold, because only tracked mutations can be counted to find their dosage") }
+	freqDependent := strings.ToLower(c.Selection.Frequency_dependent_model) != "none"
+	if freqDependent && c.Computation.Tracking_threshold != 0.0 { return errors.New("frequency_dependent_model can not be used with a non-zero tracking_threshold, because the mutations below it are pooled into their LB and their frequencies are not known") }
+	if !c.Mutations.Zygosity_fitness && !freqDependent && c.Population.Ploidy <= 2 && c.Population.Imprinted_regions == "" && !FMgr.IsFile(MUTATION_ORIGIN_FILENAME) && !FMgr.IsFile(FST_FILENAME) && !FMgr.IsDir(ALLELE_BINS_DIRECTORY) && !FMgr.IsDir(NORMALIZED_ALLELE_BINS_DIRECTORY) && !FMgr.IsDir(DISTRIBUTION_DEL_DIRECTORY) && !FMgr.IsDir(DISTRIBUTION_FAV_DIRECTORY) {
+		// Note: zygosity_fitness and polyploid dosage need the mutations tracked to find the number of copies of each, imprinting needs them tracked to give the expressed copy their full effect, and frequency dependent selection needs them tracked to know their frequencies, and the mutation origin and fst files count the tracked mutations, so we leave tracking_threshold alone in those cases
 		log.Printf("Since %v, %v, %v, and %v were not requested to be written, setting tracking_threshold=9.0 to save space/time\n", ALLELE_BINS_DIRECTORY, NORMALIZED_ALLELE_BINS_DIRECTORY, DISTRIBUTION_DEL_DIRECTORY, DISTRIBUTION_FAV_DIRECTORY)
 		c.Computation.Tracking_threshold = 9.0
 	}
 	//if c.Computation.Track_neutrals && c.Computation.Tracking_threshold != 0.0 { c.Computation.Track_neutrals = false }
 
+	if strings.ToLower(c.Mutations.Dominance_model) == "hs-relationship" {
+		if c.Mutations.Dominance_hs_k <= 0.0 { return errors.New("if dominance_model==hs-relationship, dominance_hs_k must be > 0.0") }
+		if isUserSet("mutations", "fraction_recessive") { return errors.New("fraction_recessive can not be set when dominance_model==hs-relationship, because h is determined by the fitness effect of each mutation") }
+	}
+
+	if c.Mutations.Fraction_lethal < 0.0 || c.Mutations.Fraction_sterile < 0.0 || c.Mutations.Fraction_lethal + c.Mutations.Fraction_sterile > 1.0 { return errors.New("fraction_lethal and fraction_sterile must be >= 0.0 and their sum must be <= 1.0") }
+	if c.Mutations.Fraction_recessive_lethal < 0.0 || c.Mutations.Fraction_recessive_lethal > 1.0 { return errors.New("fraction_recessive_lethal must be between 0.0 and 1.0") }
+
+	if strings.ToLower(c.Selection.Environment_model) == "none" {
+		c.Mutations.Fraction_trait_mutn = 0.0		// there is no trait for these mutations to affect
+	} else {
+		if c.Mutations.Fraction_trait_mutn <= 0.0 || c.Mutations.Fraction_trait_mutn > 1.0 { return errors.New("if environment_model is not none, fraction_trait_mutn must be > 0.0 and <= 1.0") }
+		if c.Mutations.Trait_mutn_sd <= 0.0 { return errors.New("if environment_model is not none, trait_mutn_sd must be > 0.0") }
+		if c.Selection.Selection_width <= 0.0 { return errors.New("if environment_model is not none, selection_width must be > 0.0") }
+		if c.Mutations.Num_traits < 1 { return errors.New("if environment_model is not none, num_traits must be >= 1") }
+		// The correlation matrix with the same correlation between every pair of traits is only positive definite in this range
+		if c.Mutations.Num_traits > 1 && (c.Mutations.Trait_correlation >= 1.0 || c.Mutations.Trait_correlation <= -1.0 / float64(c.Mutations.Num_traits - 1)) {
+			return errors.New("trait_correlation must be < 1.0 and > -1/(num_traits-1)")
+		}
+	}
+
+	if strings.ToLower(c.Mutations.Mutn_rate_model) == "negative-binomial" && c.Mutations.Mutn_rate_dispersion <= 0.0 { return errors.New("if mutn_rate_model is negative-binomial, mutn_rate_dispersion must be > 0.0") }
+	if c.Mutations.Mutn_rate_heterogeneity < 0.0 { return errors.New("mutn_rate_heterogeneity can not be < 0.0") }
+	if c.Mutations.Fraction_mutator < 0.0 || c.Mutations.Fraction_mutator >= 1.0 { return errors.New("fraction_mutator must be >= 0.0 and < 1.0") }
+	if c.Mutations.Fraction_mutator > 0.0 && (c.Mutations.Mutator_effect_sd <= 0.0 || c.Mutations.Mutator_max_factor < 1.0) {
+		return errors.New("if fraction_mutator > 0.0, mutator_effect_sd must be > 0.0 and mutator_max_factor must be >= 1.0")
+	}
+	if c.Mutations.Fraction_local_adaptation < 0.0 || c.Mutations.Fraction_local_adaptation >= 1.0 { return errors.New("fraction_local_adaptation must be >= 0.0 and < 1.0") }
+	if c.Mutations.Fraction_local_adaptation > 0.0 && c.Tribes.Num_tribes < 2 { return errors.New("fraction_local_adaptation can only be > 0.0 when num_tribes > 1, because each locally adapted mutation is beneficial in only 1 tribe") }
+	if c.Mutations.Fraction_local_adaptation > 0.0 && c.Tribes.Demographic_events != "" { return errors.New("fraction_local_adaptation can not be used with demographic_events, because the home tribe of each locally adapted mutation is chosen from the initial num_tribes tribes") }
+	if c.Mutations.Local_adaptation_cost < 0.0 { return errors.New("local_adaptation_cost can not be < 0.0") }
+
+	if c.Selection.Maternal_effect_weight < 0.0 || c.Selection.Maternal_effect_weight > 1.0 { return errors.New("maternal_effect_weight must be between 0.0 and 1.0") }
+	if c.Selection.Maternal_effect_weight > 0.0 {
+		if mf := strings.ToLower(c.Selection.Maternal_effect_fitness); mf != "geno" && mf != "pheno" { return errors.New("maternal_effect_fitness must be geno or pheno") }
+	}
+
+	if freqDependent {
+		if c.Selection.Frequency_dependent_strength <= 0.0 { return errors.New("if frequency_dependent_model is not none, frequency_dependent_strength must be > 0.0") }
+		if c.Selection.Frequency_dependent_target < 0.0 || c.Selection.Frequency_dependent_target > 1.0 { return errors.New("frequency_dependent_target must be between 0.0 and 1.0") }
+	}
+
+	if strings.ToLower(c.Organelle.Organelle_model) != "none" {
+		if c.Organelle.Organelle_num_linkage_subunits == 0 { return errors.New("if organelle_model is not none, organelle_num_linkage_subunits must be > 0") }
+		if c.Organelle.Organelle_mutn_rate < 0.0 { return errors.New("organelle_mutn_rate can not be < 0.0") }
+		if c.Organelle.Organelle_frac_fav_mutn < 0.0 || c.Organelle.Organelle_frac_fav_mutn > 1.0 { return errors.New("organelle_frac_fav_mutn must be between 0.0 and 1.0") }
+		if c.Organelle.Organelle_fraction_neutral < 0.0 || c.Organelle.Organelle_fraction_neutral > 1.0 { return errors.New("organelle_fraction_neutral must be between 0.0 and 1.0") }
+		if c.Organelle.Organelle_fitness_effect_scale < 0.0 { return errors.New("organelle_fitness_effect_scale can not be < 0.0") }
+	}
+
+	if c.Mutations.Paternal_mutn_rate < 0.0 || c.Mutations.Maternal_mutn_rate < 0.0 { return errors.New("paternal_mutn_rate and maternal_mutn_rate can not be < 0.0") }
+	if c.Mutations.Paternal_mutn_rate > 0.0 || c.Mutations.Maternal_mutn_rate > 0.0 {
+		if c.Population.Ploidy == 1 { return errors.New("paternal_mutn_rate and maternal_mutn_rate can not be used with ploidy=1") }
+		if isUserSet("mutations", "mutn_rate") && c.Mutations.Mutn_rate != c.Mutations.Paternal_mutn_rate + c.Mutations.Maternal_mutn_rate {
+			return errors.New("mutn_rate must either not be set or be equal to paternal_mutn_rate + maternal_mutn_rate")
+		}
+		c.Mutations.Mutn_rate = c.Mutations.Paternal_mutn_rate + c.Mutations.Maternal_mutn_rate
+		log.Printf("Since paternal_mutn_rate or maternal_mutn_rate was set, setting mutn_rate to their sum: %v\n", c.Mutations.Mutn_rate)
+	}
+	if c.Population.Ploidy == 0 || (c.Population.Ploidy > 1 && c.Population.Ploidy % 2 != 0) { return errors.New("ploidy must be 1 or an even number") }
+	if c.Population.Ploidy > 2 {
+		if c.Population.Num_contrasting_alleles > 0 || c.Population.Initial_inversions != "" || c.Population.Inversion_mutn_rate > 0.0 || ((c.Mutations.Fraction_lethal > 0.0 || c.Mutations.Fraction_sterile > 0.0) && c.Mutations.Fraction_recessive_lethal > 0.0) {
+			return errors.New("ploidy > 2 can not be used with num_contrasting_alleles, initial_inversions, inversion_mutn_rate, or fraction_recessive_lethal")
+		}
+		if c.Mutations.Multiplicative_weighting != 0.0 { return errors.New("ploidy > 2 can not be used with multiplicative_weighting") }
+	}
+	if c.Population.Ploidy == 1 {
+		if c.Mutations.Zygosity_fitness || c.Population.Num_contrasting_alleles > 0 || c.Population.Initial_inversions != "" || c.Population.Inversion_mutn_rate > 0.0 || strings.ToLower(c.Mutations.Dominance_model) != "fixed" {
+			return errors.New("ploidy=1 can not be used with zygosity_fitness, num_contrasting_alleles, initial_inversions, inversion_mutn_rate, or dominance_model=hs-relationship, because they all depend on having 2 chromosome sets")
+		}
+		if c.Population.Haploid_fusion_rate < 0.0 || c.Population.Haploid_fusion_rate > 1.0 { return errors.New("haploid_fusion_rate must be between 0.0 and 1.0") }
+		c.Mutations.Fraction_recessive = 0.0		// haploids have only 1 copy of each mutation, so every mutation is fully expressed
+	}
+
+	if c.Population.Imprinted_regions != "" && (c.Population.Ploidy != 2 || c.Mutations.Zygosity_fitness) {
+		return errors.New("imprinted_regions can only be used with ploidy=2 and zygosity_fitness=false, because only 1 of the 2 copies of an imprinted LB is expressed")
+	}
+	if c.Population.Imprinted_regions != "" && c.Computation.Tracking_threshold != 0.0 { return errors.New("imprinted_regions can not be used with a non-zero tracking_threshold, because only tracked mutations can be given their full effect on the expressed copy") }
+
+	if c.Population.Catastrophe_rate < 0.0 || c.Population.Catastrophe_rate > 1.0 { return errors.New("catastrophe_rate must be >= 0.0 and <= 1.0") }
+	if c.Population.Catastrophe_rate > 0.0 {
+		if c.Population.Catastrophe_max_cull <= 0.0 || c.Population.Catastrophe_max_cull > 1.0 { return errors.New("catastrophe_max_cull must be > 0.0 and <= 1.0 when catastrophe_rate > 0.0") }
+		if c.Population.Catastrophe_max_penalty <= 0.0 || c.Population.Catastrophe_max_penalty > 1.0 { return errors.New("catastrophe_max_penalty must be > 0.0 and <= 1.0 when catastrophe_rate > 0.0") }
+		if c.Population.Catastrophe_penalty_gens == 0 { return errors.New("catastrophe_penalty_gens must be > 0 when catastrophe_rate > 0.0") }
+	}
+
+	if (FMgr.IsFile(PEDIGREE_FILENAME) || FMgr.IsFile(INBREEDING_FILENAME)) && c.Population.Ploidy != 2 {
+		return errors.New("the pedigree and inbreeding files can only be output with ploidy=2")
+	}
+	if (FMgr.IsFile(PEDIGREE_FILENAME) || FMgr.IsFile(INBREEDING_FILENAME)) && c.Basic.Pop_size > MAX_PEDIGREE_POP_SIZE {
+		return errors.New("the pedigree and inbreeding files can only be output with pop_size <= "+strconv.Itoa(MAX_PEDIGREE_POP_SIZE)+", because the kinship matrix uses memory proportional to the square of the population size")
+	}
+
+	if FMgr.IsDir(TREE_SEQUENCE_DIRECTORY) && c.Computation.Tree_sequence_simplify_interval == 0 {
+		return errors.New("tree_sequence_simplify_interval must be > 0 when tree-sequence/ is in files_to_output")
+	}
+
+	if FMgr.IsFile(FST_FILENAME) && c.Computation.Fst_gens == 0 { return errors.New("fst_gens must be > 0 when mendel.fst is in files_to_output") }
+	if FMgr.IsFile(FST_FILENAME) && c.Computation.Tracking_threshold >= 1.0 { return errors.New("mendel.fst output was requested, but no allele frequencies can be compared when tracking_threshold >= 1.0") }
+	if strings.ToLower(c.Tribes.Lattice_model) != "none" && c.Tribes.Demographic_events != "" { return errors.New("demographic_events can not be used with a lattice_model, because the tribes they create would not have a place on the lattice") }
+	if strings.ToLower(c.Population.Pop_growth_model) == "trajectory" && c.Tribes.Demographic_events != "" { return errors.New("demographic_events can not be used with pop_growth_model=trajectory, because the trajectory would overwrite the target sizes set by the split and resize events") }
+	if c.Tribes.Dispersal_rate < 0.0 || c.Tribes.Dispersal_rate > 1.0 { return errors.New("dispersal_rate must be >= 0.0 and <= 1.0") }
+	if c.Tribes.Dispersal_rate > 0.0 && c.Tribes.Dispersal_scale <= 0.0 { return errors.New("dispersal_scale must be > 0.0 when dispersal_rate > 0.0") }
+
+	if (c.Tribes.Demographic_events != "" || c.Tribes.Recolonization_delay > 0 || c.Tribes.Dispersal_rate > 0.0) && (FMgr.IsFile(PEDIGREE_FILENAME) || FMgr.IsFile(INBREEDING_FILENAME) || FMgr.IsDir(TREE_SEQUENCE_DIRECTORY)) {
+		return errors.New("demographic_events, recolonization_delay, and dispersal_rate can not be used when the pedigree, inbreeding, or tree sequence is output, because those assume the tribes do not exchange individuals")
+	}
+
 	if c.Computation.Num_threads == 0 { c.Computation.Num_threads = uint32(runtime.NumCPU()) }
 
 	if c.Tribes.Num_tribes <= 0 { return errors.New("num_tribes can not be <= 0") }
+	if c.Tribes.Recolonization_delay > 0 && c.Tribes.Num_recolonizers < 2 { return errors.New("num_recolonizers must be >= 2 when recolonization_delay > 0, so the recolonized tribe can mate") }
+
+	if c.Population.Inversion_mutn_rate < 0.0 { return errors.New("inversion_mutn_rate can not be < 0.0") }
+	if c.Population.Inversion_mutn_rate > 0.0 && (c.Population.Max_inversion_length < 2 || c.Population.Max_inversion_length > c.Population.Num_linkage_subunits / c.Population.Haploid_chromosome_number) {
+		return errors.New("if inversion_mutn_rate > 0.0, max_inversion_length must be >= 2 and <= the number of linkage blocks per chromosome")
+	}
 
 	return nil
 }
@@ -233,10 +438,35 @@ func ComputedValuesFactory() (c *ComputedValues) {
 			c.Fav_scale = 0.
 		}
 	}
+
+	if strings.ToLower(Cfg.Selection.Environment_model) != "none" {
+		c.Trait_cholesky = choleskyEquicorrelation(int(Cfg.Mutations.Num_traits), Cfg.Mutations.Trait_correlation)
+	}
 	return
 }
 
 
+// choleskyEquicorrelation returns the lower triangular cholesky factor L (so L*L^T = R) of the n x n correlation matrix R that has rho for every off-diagonal element.
+func choleskyEquicorrelation(n int, rho float64) [][]float64 {
+	l := make([][]float64, n)
+	for i := 0; i < n; i++ {
+		l[i] = make([]float64, i+1)
+		for j := 0; j <= i; j++ {
+			r := rho
+			if i == j { r = 1.0 }
+			sum := 0.0
+			for k := 0; k < j; k++ { sum += l[i][k] * l[j][k] }
+			if i == j {
+				l[i][j] = math.Sqrt(r - sum)
+			} else {
+				l[i][j] = (r - sum) / l[j][j]
+			}
+		}
+	}
+	return l
+}
+
+
 // FindDefaultFile looks for the defaults input file and returns the 1st one it finds. It exits with error if it can't find one.
 func FindDefaultFile() string {
 	// If they explicitly told us on the cmd line where it is use that
@@ -14,10 +14,24 @@ const (
 	FITNESS_FILENAME = "mendel.fit"		// this one is faster to produce than mendel.hst
 	TOML_FILENAME = "mendel_go.toml"		// the input parameters
 	OUTPUT_FILENAME = "mendel_go.out"		//todo: figure out how we can get our own output into this file
+	INVERSIONS_FILENAME = "mendel.inv"		// only produced when inversions are enabled
+	DEATHS_FILENAME = "mendel.dth"		// only produced when lethal or sterility mutations are enabled
+	ENVIRONMENT_FILENAME = "mendel.env"		// only produced when environment_model is not none
+	ORGANELLE_FILENAME = "mendel.org"		// only produced when organelle_model is not none
+	MUTATION_ORIGIN_FILENAME = "mendel.ori"		// only produced when paternal_mutn_rate or maternal_mutn_rate is set
+	IMPRINTING_FILENAME = "mendel.imp"		// only produced when imprinted_regions is set
+	MUTATOR_FILENAME = "mendel.mtr"		// only produced when fraction_mutator > 0
+	NEW_MUTATION_COUNTS_FILENAME = "mendel.nmc"		// only produced when mutn_rate_model=negative-binomial or mutn_rate_heterogeneity > 0
+	PEDIGREE_FILENAME = "mendel.ped"		// only produced when explicitly listed in files_to_output
+	INBREEDING_FILENAME = "mendel.inb"		// only produced when explicitly listed in files_to_output
+	FST_FILENAME = "mendel.fst"		// only produced when lattice_model is not none
+	LOCAL_ADAPTATION_FILENAME = "mendel.loc"		// only produced when fraction_local_adaptation > 0
+	CATASTROPHE_FILENAME = "mendel.cat"		// only produced when catastrophe_rate > 0
 	ALLELE_BINS_DIRECTORY = "allele-bins/"
 	NORMALIZED_ALLELE_BINS_DIRECTORY = "normalized-allele-bins/"
 	DISTRIBUTION_DEL_DIRECTORY = "allele-distribution-del/"
 	DISTRIBUTION_FAV_DIRECTORY = "allele-distribution-fav/"
+	TREE_SEQUENCE_DIRECTORY = "tree-sequence/"		// only produced when explicitly listed in files_to_output
 )
 
 // Not using buffered io because we need write to be flushed every generation to support restart
@@ -31,6 +45,7 @@ type FileMgr struct {
 	DataFilePath string                         // the directory in which output files should go
 	Files        map[string]*os.File            // key is filename, value is file descriptor (nil if not opened yet)
 	Dirs         map[string]map[string]*os.File // directories that hold a group of output files. Key is dir name, value is map in which key is filename, value is file descriptor (nil if not opened yet)
+	fileNames    []string                       // the files and dirs requested in files_to_output, so they can also be opened for tribes created during the run
 }
 
 // FMgr is the singleton instance of FileMgr, created by FileMgrFactory.
@@ -43,7 +58,7 @@ func FileMgrFactory(dataFilePath, filesToOutput string) *FileMgr {
 	if filesToOutput == "" { return FMgr }
 
 	// Get the proper list of file names
-	var VALID_FILE_NAMES = map[string]int{HISTORY_FILENAME: 1, FITNESS_FILENAME: 1, ALLELE_BINS_DIRECTORY: 1, NORMALIZED_ALLELE_BINS_DIRECTORY: 1, DISTRIBUTION_DEL_DIRECTORY: 1, DISTRIBUTION_FAV_DIRECTORY: 1,}
+	var VALID_FILE_NAMES = map[string]int{HISTORY_FILENAME: 1, FITNESS_FILENAME: 1, ALLELE_BINS_DIRECTORY: 1, NORMALIZED_ALLELE_BINS_DIRECTORY: 1, DISTRIBUTION_DEL_DIRECTORY: 1, DISTRIBUTION_FAV_DIRECTORY: 1, INVERSIONS_FILENAME: 1, DEATHS_FILENAME: 1, ENVIRONMENT_FILENAME: 1, ORGANELLE_FILENAME: 1, MUTATION_ORIGIN_FILENAME: 1, IMPRINTING_FILENAME: 1, MUTATOR_FILENAME: 1, NEW_MUTATION_COUNTS_FILENAME: 1, PEDIGREE_FILENAME: 1, INBREEDING_FILENAME: 1, FST_FILENAME: 1, LOCAL_ADAPTATION_FILENAME: 1, CATASTROPHE_FILENAME: 1, TREE_SEQUENCE_DIRECTORY: 1,}
 	if CmdArgs.CreateZip || CmdArgs.SPCusername != "" {
 		// Add the files that are relevant only when -u is specified (and we aren't running in spc)
 		VALID_FILE_NAMES[TOML_FILENAME] = 1
@@ -54,6 +69,7 @@ func FileMgrFactory(dataFilePath, filesToOutput string) *FileMgr {
 		// They want all files/dirs output
 		fileNames = make([]string, 0, len(VALID_FILE_NAMES))
 		for k := range VALID_FILE_NAMES {
+			if !isFeatureFileEnabled(k) { continue }
 			fileNames = append(fileNames, k)
 		}
 	} else {
@@ -70,8 +86,9 @@ func FileMgrFactory(dataFilePath, filesToOutput string) *FileMgr {
 
 	// Open all of the files and put in the map
 	Verbose(5, "Opening files for writing: %v", fileNames)
+	FMgr.fileNames = fileNames
 	FMgr.openFiles(dataFilePath, "", fileNames)		// this is either for the single pop, or a summary of all the tribes
-	if Cfg.Tribes.Num_tribes > 1 {
+	if MultipleTribes() {
 		for i:=1; i<=int(Cfg.Tribes.Num_tribes); i++ {
 			FMgr.openFiles(dataFilePath, TribeDir(uint32(i)), fileNames)
 		}
@@ -80,13 +97,55 @@ func FileMgrFactory(dataFilePath, filesToOutput string) *FileMgr {
 	return FMgr		// return the object created so we can chain other methods after this
 }
 
+// isFeatureFileEnabled returns false for output files that only have content when an optional feature is turned on in the input file
+// and that feature is off. This keeps files_to_output=* from creating files that would be empty.
+func isFeatureFileEnabled(fileName string) bool {
+	switch fileName {
+	case INVERSIONS_FILENAME:
+		return Cfg.Population.Initial_inversions != "" || Cfg.Population.Inversion_mutn_rate > 0.0
+	case DEATHS_FILENAME:
+		return Cfg.Mutations.Fraction_lethal > 0.0 || Cfg.Mutations.Fraction_sterile > 0.0
+	case ENVIRONMENT_FILENAME:
+		return strings.ToLower(Cfg.Selection.Environment_model) != "none"
+	case ORGANELLE_FILENAME:
+		return strings.ToLower(Cfg.Organelle.Organelle_model) != "none"
+	case MUTATION_ORIGIN_FILENAME:
+		return Cfg.Mutations.Paternal_mutn_rate > 0.0 || Cfg.Mutations.Maternal_mutn_rate > 0.0
+	case IMPRINTING_FILENAME:
+		return Cfg.Population.Imprinted_regions != ""
+	case MUTATOR_FILENAME:
+		return Cfg.Mutations.Fraction_mutator > 0.0
+	case LOCAL_ADAPTATION_FILENAME:
+		return Cfg.Mutations.Fraction_local_adaptation > 0.0
+	case CATASTROPHE_FILENAME:
+		return Cfg.Population.Catastrophe_rate > 0.0
+	case PEDIGREE_FILENAME, INBREEDING_FILENAME:
+		return false		// the pedigree is expensive to track for large populations, so it has to be requested explicitly
+	case FST_FILENAME:
+		return strings.ToLower(Cfg.Tribes.Lattice_model) != "none"
+	case TREE_SEQUENCE_DIRECTORY:
+		return false		// recording the genealogy of every chromosome is expensive, so it has to be requested explicitly
+	case NEW_MUTATION_COUNTS_FILENAME:
+		return strings.ToLower(Cfg.Mutations.Mutn_rate_model) == "negative-binomial" || Cfg.Mutations.Mutn_rate_heterogeneity > 0.0
+	}
+	return true
+}
+
+// MultipleTribes returns true if the run has (or can come to have, thru demographic events) more than 1 tribe, so each tribe gets its own output dir.
+func MultipleTribes() bool { return Cfg.Tribes.Num_tribes > 1 || Cfg.Tribes.Demographic_events != "" }
+
 func TribeDir(tribeNum uint32) string { return "tribe-"+strconv.Itoa(int(tribeNum)) }
 
 func TribePrefix(tribeNum uint32) string {
-	if Cfg.Tribes.Num_tribes <= 1 || tribeNum == 0 { return "" }
+	if !MultipleTribes() || tribeNum == 0 { return "" }
 	return TribeDir(tribeNum) + "/"
 }
 
+// OpenTribeFiles opens the requested output files and dirs for a tribe that was created during the run by a demographic event.
+func (fMgr *FileMgr) OpenTribeFiles(tribeNum uint32) {
+	fMgr.openFiles(fMgr.DataFilePath, TribeDir(tribeNum), fMgr.fileNames)
+}
+
 // openFiles opens the files and creates the dirs for the main pop (subdir=="") or a tribe.
 func (fMgr *FileMgr) openFiles(dataFilePath, subdir string, fileNames []string) {
 	dataFilePath = suffixDir(dataFilePath,subdir)		// this is usually a subdir for a tribe
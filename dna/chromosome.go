@@ -11,6 +11,10 @@ import (
 type Chromosome struct {
 	LinkageBlocks []LinkageBlock
 	FitnessEffect float32	// keep a running total of the fitness contribution of this LB to the chromosome
+	Inversions []Inversion	// the inversions this chromosome carries, in order of position. Usually empty.
+	Node int32		// the tree sequence node of the genome this chromosome belongs to (only used when the tree sequence is recorded)
+	Segments []Segment		// the LB ranges inherited from each parent genome during mating, until they are added to the tree sequence
+	NewMutations []NewMutationRecord		// the mutations added during mating, until they are added to the tree sequence
 }
 
 
@@ -26,6 +30,7 @@ func (c *Chromosome) ChromosomeFactory(lBsPerChromosome uint32) {
 // In the other Chromosome methods we can tell if the recycled chromosome exists because the ptr to it will be non-nil.
 func (c *Chromosome) Reinitialize() {
 	c.FitnessEffect = 0.0
+	c.Inversions = c.Inversions[:0]
 }
 
 
@@ -52,6 +57,11 @@ func (c *Chromosome) TransferLB(newChr *Chromosome, lbIndex int) (uint32, uint32
 
 	// Housekeeping for the new chromo
 	newChr.FitnessEffect += newChr.LinkageBlocks[lbIndex].SumFitness()
+	// An inversion is inherited along with its 1st LB. The crossover models ensure the rest of its LBs come from this chromosome too (unless newChr's other parent chromosome also has it).
+	for _, inv := range c.Inversions {
+		if int(inv.Start) == lbIndex { newChr.Inversions = append(newChr.Inversions, inv) }
+	}
+	if Mdl.RecordTreeSequence { newChr.recordSegment(c.Node, lbIndex) }
 	return newChr.LinkageBlocks[lbIndex].GetMutationStats()
 }
 
@@ -59,6 +69,12 @@ func (c *Chromosome) TransferLB(newChr *Chromosome, lbIndex int) (uint32, uint32
 // GetNumLinkages returns the number of linkage blocks from each parent (we assume they always have the same number of LBs from each parent)
 func (c *Chromosome) GetNumLinkages() uint32 { return uint32(len(c.LinkageBlocks)) }
 
+// GetNumMutations returns the number of tracked mutations on this chromosome
+func (c *Chromosome) GetNumMutations() (num uint32) {
+	for i := range c.LinkageBlocks { num += uint32(len(c.LinkageBlocks[i].mutn)) }
+	return
+}
+
 
 /* Not used right now because it simply calls the crossover model function, but may bring it back if there is more to do...
 // Meiosis fills in a child chromosome as part of reproduction by implementing the crossover model specified in the config file.
@@ -86,10 +102,14 @@ func NoCrossover(dad *Chromosome, mom *Chromosome, offspr *Chromosome, _ uint32,
 
 // Create the gamete from dad and mom's chromosomes by randomly choosing each LB from either. Returns the number of each kind of mutation in the new chromosome.
 func FullCrossover(dad *Chromosome, mom *Chromosome, offspr *Chromosome, _ uint32, uniformRandom *rand.Rand) (deleterious, neutral, favorable, delAllele, favAllele uint32) {
+	regions := suppressedRegions(dad, mom)		// crossovers can not happen within these
+	fromDad := false
 	// Each LB can come from either dad or mom
 	for lbIndex :=0; lbIndex <int(dad.GetNumLinkages()); lbIndex++ {
 		var delet, neut, fav, delAll, favAll uint32
-		if uniformRandom.Intn(2) == 0 {
+		// Within a suppressed region, every LB has to come from the same parent chromosome as the 1st LB of the region
+		if r := regionContaining(regions, lbIndex); r == nil || lbIndex == int(r.Start) { fromDad = uniformRandom.Intn(2) == 0 }
+		if fromDad {
 			delet, neut, fav, delAll, favAll = dad.TransferLB(offspr, lbIndex)
 		} else {
 			delet, neut, fav, delAll, favAll = mom.TransferLB(offspr, lbIndex)
@@ -137,6 +157,7 @@ func PartialCrossover(dad *Chromosome, mom *Chromosome, offspr *Chromosome, lBsP
 		numLbSections = 2 * numCrossovers
 	}
 	meanSectionSize := utils.RoundIntDiv(float64(lBsPerChromosome), float64(numLbSections))
+	regions := suppressedRegions(dad, mom)		// crossovers can not happen within these
 
 	// Copy each LB section.
 	begIndex := 0		// points to the beginning of the next LB section
@@ -154,6 +175,7 @@ func PartialCrossover(dad *Chromosome, mom *Chromosome, offspr *Chromosome, lBsP
 		}
 		endIndex := utils.MinInt(begIndex+sectionLen-1, maxIndex)
 		if section >=  numLbSections { endIndex = maxIndex }		// make the last section reach to the end of the chromosome
+		if r := regionContaining(regions, endIndex); r != nil { endIndex = int(r.End) }		// a crossover can not happen within a suppressed region, so move it to the end of the region
 		for lbIndex :=begIndex; lbIndex <=endIndex; lbIndex++ {
 			delet, neut, fav, delAll, favAll := parent.TransferLB(offspr, lbIndex)
 			deleterious += delet
@@ -181,6 +203,7 @@ func (c *Chromosome) AppendMutation(lbInChr int, mutId uint64, uniformRandom *ra
 	//		of calculating its own fitness, so we won't do that.
 	mType, fitnessEffect := c.LinkageBlocks[lbInChr].AppendMutation(mutId, uniformRandom)
 	c.FitnessEffect += fitnessEffect
+	if Mdl.RecordTreeSequence { c.NewMutations = append(c.NewMutations, NewMutationRecord{LB: uint32(lbInChr), Type: mType, Id: mutId}) }
 	return mType
 }
 
@@ -206,6 +229,35 @@ func (c *Chromosome) SumFitness() float64 {
 }
 
 
+// HomozygousCorrection returns the fitness adjustment needed for the mutations this chromosome has in common with the other chromosome of the pair.
+// See LinkageBlock.HomozygousCorrection().
+func (c *Chromosome) HomozygousCorrection(other *Chromosome) (correction float64) {
+	for i := range c.LinkageBlocks {
+		correction += c.LinkageBlocks[i].HomozygousCorrection(&other.LinkageBlocks[i])
+	}
+	return
+}
+
+
+// SumTrackedMutations returns the sum of effectFunc applied to each of the tracked mutations on this chromosome for which isInClass returns true
+func (c *Chromosome) SumTrackedMutations(isInClass func(MutationType) bool, effectFunc func(Mutation) float64) (sum float64) {
+	for i := range c.LinkageBlocks {
+		for _, m := range c.LinkageBlocks[i].mutn {
+			if isInClass(m.Type) { sum += effectFunc(m) }
+		}
+	}
+	return
+}
+
+
+// AddTraitValues adds the combined effect of all of the trait mutations on this chromosome to the value of each quantitative trait in traits
+func (c *Chromosome) AddTraitValues(traits []float64) {
+	for i := range c.LinkageBlocks {
+		for t, value := range c.LinkageBlocks[i].TraitValues() { traits[t] += float64(value) }
+	}
+}
+
+
 // CountAlleles adds all of this chromosome's alleles (both mutations and initial alleles) to the given struct
 func (c *Chromosome) CountAlleles(allelesForThisIndiv *AlleleCount) {
 	for _, lb := range c.LinkageBlocks { lb.CountAlleles(allelesForThisIndiv) }
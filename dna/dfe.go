@@ -0,0 +1,316 @@
+package dna
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/random"
+)
+
+// This file contains the distributions of fitness effects (DFEs) other than the original fixed, uniform, and weibull (which are in mutation.go).
+// The gamma, lognormal, and exponential distributions are parameterized by their mean (dfe_mean_del, dfe_mean_fav), so that they are easy
+// to compare with each other. Deleterious effects are capped at 1.0 and favorable effects at max_fav_fitness_gain, like weibull is.
+
+// limitDel and limitFav apply the caps to the magnitude of an effect and give it the correct sign
+func limitDel(s float64) float64 { return -math.Min(s, 1.0) }
+func limitFav(s float64) float64 { return math.Min(s, config.Cfg.Mutations.Max_fav_fitness_gain) }
+
+// Gamma distribution with shape dfe_gamma_shape
+func CalcGammaDelMutationFitness(uniformRandom *rand.Rand) float64 {
+	shape := config.Cfg.Mutations.Dfe_gamma_shape
+	return limitDel(random.Gamma(uniformRandom, shape, config.Cfg.Mutations.Dfe_mean_del / shape))
+}
+func CalcGammaFavMutationFitness(uniformRandom *rand.Rand) float64 {
+	shape := config.Cfg.Mutations.Dfe_gamma_shape
+	return limitFav(random.Gamma(uniformRandom, shape, config.Cfg.Mutations.Dfe_mean_fav / shape))
+}
+
+// Reflected gamma: the favorable effects are the mirror image of the deleterious ones (the same gamma distribution with mean dfe_mean_del),
+// so the only difference between them is how often they occur (frac_fav_mutn).
+func CalcReflectedGammaFavMutationFitness(uniformRandom *rand.Rand) float64 {
+	shape := config.Cfg.Mutations.Dfe_gamma_shape
+	return limitFav(random.Gamma(uniformRandom, shape, config.Cfg.Mutations.Dfe_mean_del / shape))
+}
+
+// Lognormal distribution, in which the log of the effect has standard deviation dfe_lognormal_sigma
+func CalcLognormalDelMutationFitness(uniformRandom *rand.Rand) float64 {
+	sigma := config.Cfg.Mutations.Dfe_lognormal_sigma
+	return limitDel(math.Exp(lognormalMu(config.Cfg.Mutations.Dfe_mean_del, sigma) + sigma * uniformRandom.NormFloat64()))
+}
+func CalcLognormalFavMutationFitness(uniformRandom *rand.Rand) float64 {
+	sigma := config.Cfg.Mutations.Dfe_lognormal_sigma
+	return limitFav(math.Exp(lognormalMu(config.Cfg.Mutations.Dfe_mean_fav, sigma) + sigma * uniformRandom.NormFloat64()))
+}
+
+// lognormalMu returns the mean of the log of the effect that gives the lognormal distribution the specified mean
+func lognormalMu(mean, sigma float64) float64 { return math.Log(mean) - sigma * sigma / 2.0 }
+
+// Exponential distribution
+func CalcExponentialDelMutationFitness(uniformRandom *rand.Rand) float64 {
+	return limitDel(uniformRandom.ExpFloat64() * config.Cfg.Mutations.Dfe_mean_del)
+}
+func CalcExponentialFavMutationFitness(uniformRandom *rand.Rand) float64 {
+	return limitFav(uniformRandom.ExpFloat64() * config.Cfg.Mutations.Dfe_mean_fav)
+}
+
+
+// These are the algorithms for computing the reference curves of the allele distribution output (see pop.outputAlleleDistribution()).
+// Each returns the fraction of new deleterious mutations whose fitness effect magnitude is >= exp(-y), or the fraction of new favorable
+// mutations whose fitness effect is >= max_fav_fitness_gain*exp(-y). Pointers to 2 of them are chosen at initialization time, to go with
+// the fitness effect model.
+type CalcFitnessLogCDFType func(y float64) float64
+
+// survivalDel and survivalFav convert a function that returns the fraction of effects >= t into the form above
+func survivalDel(survival func(t float64) float64) CalcFitnessLogCDFType {
+	return func(y float64) float64 { return survival(math.Exp(-y)) }
+}
+func survivalFav(survival func(t float64) float64) CalcFitnessLogCDFType {
+	return func(y float64) float64 { return survival(config.Cfg.Mutations.Max_fav_fitness_gain * math.Exp(-y)) }
+}
+
+func gammaSurvival(mean float64) func(t float64) float64 {
+	shape := config.Cfg.Mutations.Dfe_gamma_shape
+	return func(t float64) float64 { return regularizedGammaQ(shape, t * shape / mean) }
+}
+
+func lognormalSurvival(mean float64) func(t float64) float64 {
+	sigma := config.Cfg.Mutations.Dfe_lognormal_sigma
+	mu := lognormalMu(mean, sigma)
+	return func(t float64) float64 { return 0.5 * math.Erfc((math.Log(t) - mu) / (sigma * math.Sqrt2)) }
+}
+
+func exponentialSurvival(mean float64) func(t float64) float64 {
+	return func(t float64) float64 { return math.Exp(-t / mean) }
+}
+
+// regularizedGammaQ returns the upper regularized incomplete gamma function Q(a,x), which is the fraction of a gamma distribution
+// with shape a and scale 1 that is >= x. Uses the series (for x < a+1) and continued fraction (otherwise) from Numerical Recipes.
+func regularizedGammaQ(a, x float64) float64 {
+	const maxIterations = 1000
+	const eps = 1e-14
+	if x <= 0.0 { return 1.0 }
+	lgammaA, _ := math.Lgamma(a)
+	prefix := math.Exp(-x + a * math.Log(x) - lgammaA)
+
+	if x < a + 1.0 {
+		// Series for the lower function P(a,x)
+		ap := a
+		sum := 1.0 / a
+		del := sum
+		for n := 0; n < maxIterations; n++ {
+			ap++
+			del *= x / ap
+			sum += del
+			if math.Abs(del) < math.Abs(sum) * eps { break }
+		}
+		return 1.0 - sum * prefix
+	}
+
+	// Continued fraction for Q(a,x), using the modified Lentz method
+	const tiny = 1e-300
+	b := x + 1.0 - a
+	c := 1.0 / tiny
+	d := 1.0 / b
+	h := d
+	for i := 1; i <= maxIterations; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2.0
+		d = an * d + b
+		if math.Abs(d) < tiny { d = tiny }
+		c = b + an / c
+		if math.Abs(c) < tiny { c = tiny }
+		d = 1.0 / d
+		del := d * c
+		h *= del
+		if math.Abs(del - 1.0) < eps { break }
+	}
+	return prefix * h
+}
+
+
+// mixtureComponent is 1 of the distributions in dfe_mixture, with the probability of a mutation's effect coming from it
+type mixtureComponent struct {
+	weight float64
+	calcDel, calcFav CalcMutationFitnessType
+	delLogCDF, favLogCDF CalcFitnessLogCDFType
+}
+
+// mixture holds the parsed dfe_mixture components, with the weights normalized to sum to 1
+var mixture []mixtureComponent
+
+// parseMixture parses dfe_mixture, which is comma-separated pairs model:weight, where model is any of the other fitness effect models
+// except fixed, mixture, and empirical. The parameters that the component models use (dfe_gamma_shape, dfe_lognormal_sigma) are checked here,
+// because they are only checked in SetModels() when that model is the fitness_effect_model.
+func parseMixture(mixtureStr string) (components []mixtureComponent, err error) {
+	var totalWeight float64
+	for _, t := range strings.Split(mixtureStr, ",") {
+		parts := strings.Split(strings.TrimSpace(t), ":")
+		if len(parts) != 2 { return nil, errors.New("dfe_mixture must be like: model:weight, model:weight, ...") }
+		weight, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || weight <= 0.0 { return nil, fmt.Errorf("invalid weight in dfe_mixture element %q, it must be a number > 0", t) }
+		comp := mixtureComponent{weight: weight}
+		model := MutationFitnessModelType(strings.ToLower(strings.TrimSpace(parts[0])))
+		switch model {
+		case GAMMA_FITNESS_EFFECT, REFLECTED_GAMMA_FITNESS_EFFECT:
+			if config.Cfg.Mutations.Dfe_gamma_shape <= 0.0 { return nil, fmt.Errorf("dfe_mixture contains %v, so dfe_gamma_shape must be > 0.0", model) }
+		case LOGNORMAL_FITNESS_EFFECT:
+			if config.Cfg.Mutations.Dfe_lognormal_sigma <= 0.0 { return nil, fmt.Errorf("dfe_mixture contains %v, so dfe_lognormal_sigma must be > 0.0", model) }
+		}
+		switch model {
+		case UNIFORM_FITNESS_EFFECT:
+			comp.calcDel, comp.calcFav = CalcUniformDelMutationFitness, CalcUniformFavMutationFitness
+			comp.delLogCDF, comp.favLogCDF = UniformDelFitnessLogCDF, UniformFavFitnessLogCDF
+		case WEIBULL_FITNESS_EFFECT:
+			comp.calcDel, comp.calcFav = CalcWeibullDelMutationFitness, CalcWeibullFavMutationFitness
+			comp.delLogCDF, comp.favLogCDF = WeibullDelFitnessLogCDF, WeibullFavFitnessLogCDF
+		case GAMMA_FITNESS_EFFECT:
+			comp.calcDel, comp.calcFav = CalcGammaDelMutationFitness, CalcGammaFavMutationFitness
+			comp.delLogCDF, comp.favLogCDF = survivalDel(gammaSurvival(config.Cfg.Mutations.Dfe_mean_del)), survivalFav(gammaSurvival(config.Cfg.Mutations.Dfe_mean_fav))
+		case REFLECTED_GAMMA_FITNESS_EFFECT:
+			comp.calcDel, comp.calcFav = CalcGammaDelMutationFitness, CalcReflectedGammaFavMutationFitness
+			comp.delLogCDF, comp.favLogCDF = survivalDel(gammaSurvival(config.Cfg.Mutations.Dfe_mean_del)), survivalFav(gammaSurvival(config.Cfg.Mutations.Dfe_mean_del))
+		case LOGNORMAL_FITNESS_EFFECT:
+			comp.calcDel, comp.calcFav = CalcLognormalDelMutationFitness, CalcLognormalFavMutationFitness
+			comp.delLogCDF, comp.favLogCDF = survivalDel(lognormalSurvival(config.Cfg.Mutations.Dfe_mean_del)), survivalFav(lognormalSurvival(config.Cfg.Mutations.Dfe_mean_fav))
+		case EXPONENTIAL_FITNESS_EFFECT:
+			comp.calcDel, comp.calcFav = CalcExponentialDelMutationFitness, CalcExponentialFavMutationFitness
+			comp.delLogCDF, comp.favLogCDF = survivalDel(exponentialSurvival(config.Cfg.Mutations.Dfe_mean_del)), survivalFav(exponentialSurvival(config.Cfg.Mutations.Dfe_mean_fav))
+		default:
+			return nil, fmt.Errorf("unsupported model in dfe_mixture: %v", parts[0])
+		}
+		components = append(components, comp)
+		totalWeight += weight
+	}
+	for i := range components { components[i].weight /= totalWeight }
+	return
+}
+
+// chooseMixtureComponent randomly chooses 1 of the mixture components according to their weights
+func chooseMixtureComponent(uniformRandom *rand.Rand) *mixtureComponent {
+	rnd := uniformRandom.Float64()
+	for i := range mixture {
+		rnd -= mixture[i].weight
+		if rnd < 0.0 { return &mixture[i] }
+	}
+	return &mixture[len(mixture)-1]		// only get here due to rounding error
+}
+
+func CalcMixtureDelMutationFitness(uniformRandom *rand.Rand) float64 { return chooseMixtureComponent(uniformRandom).calcDel(uniformRandom) }
+func CalcMixtureFavMutationFitness(uniformRandom *rand.Rand) float64 { return chooseMixtureComponent(uniformRandom).calcFav(uniformRandom) }
+
+func MixtureDelFitnessLogCDF(y float64) (fraction float64) {
+	for _, comp := range mixture { fraction += comp.weight * comp.delLogCDF(y) }
+	return
+}
+func MixtureFavFitnessLogCDF(y float64) (fraction float64) {
+	for _, comp := range mixture { fraction += comp.weight * comp.favLogCDF(y) }
+	return
+}
+
+
+// empiricalEntry is 1 row of the dfe_empirical_file. If low == high it is a single effect value, otherwise it is a histogram bin whose
+// effects are uniformly distributed between low and high.
+type empiricalEntry struct {
+	low, high float64
+	cumWeight float64		// the total weight of this entry and all of the entries before it
+}
+
+// empiricalDfe holds the parsed entries of the dfe_empirical_file for 1 kind of mutation
+type empiricalDfe struct {
+	entries []empiricalEntry
+	totalWeight float64
+}
+
+var empiricalDel, empiricalFav *empiricalDfe
+
+// ReadEmpiricalDfe reads the dfe_empirical_file into empiricalDel and empiricalFav. Each non-comment line is either:
+//   del|fav  effect  [weight]
+// for a table of effects, or:
+//   del|fav  low-effect  high-effect  weight
+// for a histogram bin. Effects are the magnitude of the fitness effect (i.e. are positive for deleterious mutations too).
+func ReadEmpiricalDfe(fileName string) (err error) {
+	file, err := os.Open(fileName)
+	if err != nil { return fmt.Errorf("error opening dfe_empirical_file %v: %v", fileName, err) }
+	defer file.Close()
+
+	del, fav := &empiricalDfe{}, &empiricalDfe{}
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") { continue }
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields) > 4 { return fmt.Errorf("line %d of %v must have 2 to 4 fields", lineNum, fileName) }
+		nums := make([]float64, len(fields)-1)
+		for i := range nums {
+			nums[i], err = strconv.ParseFloat(fields[i+1], 64)
+			if err != nil { return fmt.Errorf("error parsing line %d of %v: %v", lineNum, fileName, err) }
+			if nums[i] < 0.0 { return fmt.Errorf("negative value on line %d of %v, effects are specified as magnitudes", lineNum, fileName) }
+		}
+		entry := empiricalEntry{low: nums[0], high: nums[0]}
+		weight := 1.0
+		switch len(nums) {
+		case 2:
+			weight = nums[1]
+		case 3:
+			if nums[1] < nums[0] { return fmt.Errorf("the high effect is less than the low effect on line %d of %v", lineNum, fileName) }
+			entry.high = nums[1]
+			weight = nums[2]
+		}
+
+		var dfe *empiricalDfe
+		switch strings.ToLower(fields[0]) {
+		case "del":
+			dfe = del
+		case "fav":
+			dfe = fav
+		default:
+			return fmt.Errorf("the 1st field on line %d of %v must be del or fav, not %v", lineNum, fileName, fields[0])
+		}
+		if weight == 0.0 { continue }
+		dfe.totalWeight += weight
+		entry.cumWeight = dfe.totalWeight
+		dfe.entries = append(dfe.entries, entry)
+	}
+	if err := scanner.Err(); err != nil { return fmt.Errorf("error reading %v: %v", fileName, err) }
+	if len(del.entries) == 0 { return fmt.Errorf("%v does not contain any del entries", fileName) }
+	if len(fav.entries) == 0 && config.Cfg.Mutations.Frac_fav_mutn > 0.0 { return fmt.Errorf("%v does not contain any fav entries, but frac_fav_mutn > 0", fileName) }
+	empiricalDel, empiricalFav = del, fav
+	return nil
+}
+
+// sample randomly chooses an entry according to the weights, and then an effect within it
+func (e *empiricalDfe) sample(uniformRandom *rand.Rand) float64 {
+	w := uniformRandom.Float64() * e.totalWeight
+	i := sort.Search(len(e.entries), func(i int) bool { return e.entries[i].cumWeight > w })
+	if i >= len(e.entries) { i = len(e.entries) - 1 }
+	entry := e.entries[i]
+	if entry.high == entry.low { return entry.low }
+	return entry.low + uniformRandom.Float64() * (entry.high - entry.low)
+}
+
+// survival returns the fraction of the effects that are >= t
+func (e *empiricalDfe) survival(t float64) float64 {
+	if e.totalWeight == 0.0 { return 0.0 }
+	var weight, prevCumWeight float64
+	for _, entry := range e.entries {
+		entryWeight := entry.cumWeight - prevCumWeight
+		prevCumWeight = entry.cumWeight
+		if entry.low >= t {
+			weight += entryWeight
+		} else if entry.high > t {
+			weight += entryWeight * (entry.high - t) / (entry.high - entry.low)
+		}
+	}
+	return weight / e.totalWeight
+}
+
+func CalcEmpiricalDelMutationFitness(uniformRandom *rand.Rand) float64 { return limitDel(empiricalDel.sample(uniformRandom)) }
+func CalcEmpiricalFavMutationFitness(uniformRandom *rand.Rand) float64 { return limitFav(empiricalFav.sample(uniformRandom)) }
@@ -0,0 +1,125 @@
+package dna
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+)
+
+// setDfeConfig sets the config values the DFE parsing functions use
+func setDfeConfig(gammaShape, lognormalSigma, fracFav float64) {
+	config.Cfg = &config.Config{}
+	config.Cfg.Mutations.Dfe_mean_del = 0.01
+	config.Cfg.Mutations.Dfe_mean_fav = 0.001
+	config.Cfg.Mutations.Dfe_gamma_shape = gammaShape
+	config.Cfg.Mutations.Dfe_lognormal_sigma = lognormalSigma
+	config.Cfg.Mutations.Frac_fav_mutn = fracFav
+	config.Cfg.Mutations.Max_fav_fitness_gain = 0.01
+}
+
+// Parses a valid dfe_mixture and checks that the weights are normalized and each component draws from its own distribution
+func TestParseMixture(t *testing.T) {
+	setDfeConfig(0.3, 1.0, 0.01)
+	components, err := parseMixture(" gamma:3, Exponential : 1,lognormal:4")
+	if err != nil { t.Fatalf("unexpected error: %v", err) }
+	if len(components) != 3 { t.Fatalf("expected 3 components, got %d", len(components)) }
+	for i, want := range []float64{0.375, 0.125, 0.5} {
+		if math.Abs(components[i].weight - want) > 1e-12 { t.Errorf("component %d: expected weight %v, got %v", i, want, components[i].weight) }
+	}
+
+	uniformRandom := rand.New(rand.NewSource(1))
+	for i, comp := range components {
+		if d := comp.calcDel(uniformRandom); d > 0.0 || d < -1.0 { t.Errorf("component %d: deleterious effect %v is not in [-1,0]", i, d) }
+		if f := comp.calcFav(uniformRandom); f < 0.0 || f > config.Cfg.Mutations.Max_fav_fitness_gain { t.Errorf("component %d: favorable effect %v is not in [0,max_fav_fitness_gain]", i, f) }
+		if s := comp.delLogCDF(0.0); s < 0.0 || s > 1.0 { t.Errorf("component %d: survival %v is not in [0,1]", i, s) }
+	}
+}
+
+// Checks that malformed dfe_mixture values, and components whose parameters are not set, are rejected
+func TestParseMixtureErrors(t *testing.T) {
+	tests := []struct {
+		mixture string
+		gammaShape, lognormalSigma float64
+	}{
+		{"gamma", 0.3, 1.0},		// no weight
+		{"gamma:1:2", 0.3, 1.0},		// too many fields
+		{"gamma:x", 0.3, 1.0},		// weight not a number
+		{"gamma:0", 0.3, 1.0},		// weight not > 0
+		{"gamma:1, exponential:-1", 0.3, 1.0},
+		{"fixed:1", 0.3, 1.0},		// models that can't be mixed
+		{"mixture:1", 0.3, 1.0},
+		{"empirical:1", 0.3, 1.0},
+		{"weibull:1, gamma:1", 0.0, 1.0},		// gamma component without dfe_gamma_shape
+		{"reflected-gamma:1", 0.0, 1.0},
+		{"exponential:1, lognormal:1", 0.3, 0.0},		// lognormal component without dfe_lognormal_sigma
+	}
+	for _, tc := range tests {
+		setDfeConfig(tc.gammaShape, tc.lognormalSigma, 0.01)
+		if components, err := parseMixture(tc.mixture); err == nil { t.Errorf("%q: expected an error, got %d components", tc.mixture, len(components)) }
+	}
+}
+
+// writeDfeFile writes the contents to a dfe_empirical_file in a temp dir and returns its path
+func writeDfeFile(t *testing.T, contents string) string {
+	fileName := filepath.Join(t.TempDir(), "dfe.txt")
+	if err := os.WriteFile(fileName, []byte(contents), 0644); err != nil { t.Fatalf("error writing %v: %v", fileName, err) }
+	return fileName
+}
+
+// Reads a dfe_empirical_file with table entries, a histogram bin, a 0 weight entry, and comments, and checks the resulting distributions
+func TestReadEmpiricalDfe(t *testing.T) {
+	setDfeConfig(0.0, 0.0, 0.01)
+	fileName := writeDfeFile(t, `# kind  effect(s)  weight
+del  0.001  1
+del  0.01   2
+
+# a histogram bin
+DEL  0.1  0.2  1
+del  0.5  0
+fav  0.002
+`)
+	if err := ReadEmpiricalDfe(fileName); err != nil { t.Fatalf("unexpected error: %v", err) }
+	if len(empiricalDel.entries) != 3 || empiricalDel.totalWeight != 4.0 { t.Errorf("expected 3 del entries with total weight 4, got %d with %v", len(empiricalDel.entries), empiricalDel.totalWeight) }
+	if len(empiricalFav.entries) != 1 || empiricalFav.totalWeight != 1.0 { t.Errorf("expected 1 fav entry with total weight 1, got %d with %v", len(empiricalFav.entries), empiricalFav.totalWeight) }
+
+	survivals := []struct{ t, want float64 }{
+		{0.0, 1.0},
+		{0.005, 0.75},		// the 0.01 and the bin
+		{0.15, 0.125},		// half of the bin
+		{0.2, 0.0},
+	}
+	for _, s := range survivals {
+		if got := empiricalDel.survival(s.t); math.Abs(got - s.want) > 1e-12 { t.Errorf("del survival(%v): expected %v, got %v", s.t, s.want, got) }
+	}
+
+	uniformRandom := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		e := empiricalDel.sample(uniformRandom)
+		if e != 0.001 && e != 0.01 && (e < 0.1 || e > 0.2) { t.Fatalf("sampled del effect %v is not in the file", e) }
+	}
+	if e := empiricalFav.sample(uniformRandom); e != 0.002 { t.Errorf("expected the only fav effect 0.002, got %v", e) }
+}
+
+// Checks that malformed dfe_empirical_files are rejected
+func TestReadEmpiricalDfeErrors(t *testing.T) {
+	tests := []string{
+		"del\n",		// too few fields
+		"del 0.1 0.2 1 5\n",		// too many fields
+		"del abc\n",
+		"del -0.1\n",		// effects are magnitudes
+		"del 0.2 0.1 1\n",		// high < low
+		"foo 0.1\n",
+		"# only a comment\nfav 0.01\n",		// no del entries
+		"del 0.01\n",		// no fav entries, but frac_fav_mutn > 0
+	}
+	for _, contents := range tests {
+		setDfeConfig(0.0, 0.0, 0.01)
+		if err := ReadEmpiricalDfe(writeDfeFile(t, contents)); err == nil { t.Errorf("%q: expected an error", contents) }
+	}
+	setDfeConfig(0.0, 0.0, 0.01)
+	if err := ReadEmpiricalDfe(filepath.Join(t.TempDir(), "missing.txt")); err == nil { t.Error("expected an error for a missing file") }
+}
@@ -0,0 +1,106 @@
+package dna
+
+import (
+	"math/rand"
+)
+
+// Inversion represents an inverted segment of a chromosome that spans LBs Start thru End (0 based indexes within the chromosome, inclusive).
+// Inversions are never modified once created, so a child chromosome can share them with its parent chromosome.
+type Inversion struct {
+	Id uint64
+	Start uint32
+	End uint32
+}
+
+
+// Overlaps returns true if this inversion and the other one share any LBs.
+func (inv Inversion) Overlaps(other Inversion) bool { return inv.Start <= other.End && other.Start <= inv.End }
+
+
+// HasInversion returns true if this chromosome carries the inversion with the specified id.
+func (c *Chromosome) HasInversion(id uint64) bool {
+	for _, inv := range c.Inversions {
+		if inv.Id == id { return true }
+	}
+	return false
+}
+
+
+// AddInversion adds a new inversion to this chromosome, unless it overlaps an inversion the chromosome already carries. Returns true if it was added.
+func (c *Chromosome) AddInversion(newInv Inversion) bool {
+	for _, inv := range c.Inversions {
+		if inv.Overlaps(newInv) { return false }
+	}
+	// Keep the list ordered by position, which is the order TransferLB() would give it
+	i := len(c.Inversions)
+	for i > 0 && c.Inversions[i-1].Start > newInv.Start { i-- }
+	c.Inversions = append(c.Inversions, Inversion{})
+	copy(c.Inversions[i+1:], c.Inversions[i:])
+	c.Inversions[i] = newInv
+	return true
+}
+
+
+// AddRandomInversion creates a new inversion with a random position and a length between 2 and maxLength LBs, and adds it to this chromosome.
+// Returns false if the new inversion overlapped an existing one and so was not added.
+func (c *Chromosome) AddRandomInversion(id uint64, maxLength uint32, uniformRandom *rand.Rand) bool {
+	numLBs := c.GetNumLinkages()
+	if maxLength > numLBs { maxLength = numLBs }
+	if maxLength < 2 { return false }
+	length := uint32(uniformRandom.Intn(int(maxLength-1))) + 2		// 2 - maxLength
+	start := uint32(uniformRandom.Intn(int(numLBs-length+1)))
+	return c.AddInversion(Inversion{Id: id, Start: start, End: start + length - 1})
+}
+
+
+// RegionMutationLoad returns the combined fitness effect and number of deleterious mutations of the LBs start thru end of this chromosome.
+func (c *Chromosome) RegionMutationLoad(start, end uint32) (fitnessEffect float32, numDeleterious uint32) {
+	for i := start; i <= end && i < c.GetNumLinkages(); i++ {
+		fitnessEffect += c.LinkageBlocks[i].SumFitness()
+		numDeleterious += uint32(c.LinkageBlocks[i].numDeleterious)
+	}
+	return
+}
+
+
+// suppressedRegions returns the LB ranges of the 2 homologous chromosomes in which crossovers can not occur. These are the inversions that are
+// carried by only 1 of the 2 chromosomes (the heterokaryotypic inversions), with overlapping ranges merged into a single region.
+// The regions are returned in order of position. Returns nil if there are none, which is the common case.
+func suppressedRegions(dad *Chromosome, mom *Chromosome) (regions []Inversion) {
+	if len(dad.Inversions) == 0 && len(mom.Inversions) == 0 { return nil }
+	for _, inv := range dad.Inversions {
+		if !mom.HasInversion(inv.Id) { regions = addToRegions(regions, inv) }
+	}
+	for _, inv := range mom.Inversions {
+		if !dad.HasInversion(inv.Id) { regions = addToRegions(regions, inv) }
+	}
+	return
+}
+
+// addToRegions adds the range of inv to the ordered list of regions, merging it with any regions it overlaps.
+func addToRegions(regions []Inversion, inv Inversion) []Inversion {
+	newRegion := Inversion{Start: inv.Start, End: inv.End}
+	merged := make([]Inversion, 0, len(regions)+1)
+	for _, r := range regions {
+		if r.Overlaps(newRegion) {
+			if r.Start < newRegion.Start { newRegion.Start = r.Start }
+			if r.End > newRegion.End { newRegion.End = r.End }
+		} else {
+			merged = append(merged, r)
+		}
+	}
+	i := len(merged)
+	for i > 0 && merged[i-1].Start > newRegion.Start { i-- }
+	merged = append(merged, Inversion{})
+	copy(merged[i+1:], merged[i:])
+	merged[i] = newRegion
+	return merged
+}
+
+// regionContaining returns the suppressed region that lbIndex is in, or nil if it is not in one.
+func regionContaining(regions []Inversion, lbIndex int) *Inversion {
+	for i := range regions {
+		if lbIndex >= int(regions[i].Start) && lbIndex <= int(regions[i].End) { return &regions[i] }
+	}
+	return nil
+}
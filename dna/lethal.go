@@ -0,0 +1,70 @@
+package dna
+
+import (
+	"math/rand"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+)
+
+// calcLethalSterileType determines if a new deleterious mutation is instead a lethal or sterility mutation, and if so whether it is recessive.
+// Returns mType unchanged if it is an ordinary deleterious mutation.
+func calcLethalSterileType(mType MutationType, uniformRandom *rand.Rand) MutationType {
+	rnd := uniformRandom.Float64()
+	if rnd < config.Cfg.Mutations.Fraction_lethal {
+		if uniformRandom.Float64() < config.Cfg.Mutations.Fraction_recessive_lethal { return LETHAL_RECESSIVE }
+		return LETHAL_DOMINANT
+	} else if rnd < config.Cfg.Mutations.Fraction_lethal + config.Cfg.Mutations.Fraction_sterile {
+		if uniformRandom.Float64() < config.Cfg.Mutations.Fraction_recessive_lethal { return STERILE_RECESSIVE }
+		return STERILE_DOMINANT
+	}
+	return mType
+}
+
+
+// hasMutation returns true if this LB contains the mutation with the specified id.
+func (lb *LinkageBlock) hasMutation(id uint64) bool {
+	for _, m := range lb.mutn {
+		if m.Id == id { return true }
+	}
+	return false
+}
+
+
+// LethalSterileStatus returns whether this LB and the other LB of the pair (the same LB index on the chromosome from the other parent)
+// together contain a lethal or sterility mutation that takes effect: a dominant one in either LB, or a recessive one in both.
+func (lb *LinkageBlock) LethalSterileStatus(other *LinkageBlock) (lethal, sterile bool) {
+	for _, m := range lb.mutn {
+		switch m.Type {
+		case LETHAL_DOMINANT:
+			lethal = true
+		case STERILE_DOMINANT:
+			sterile = true
+		case LETHAL_RECESSIVE:
+			if other.hasMutation(m.Id) { lethal = true }
+		case STERILE_RECESSIVE:
+			if other.hasMutation(m.Id) { sterile = true }
+		}
+	}
+	// The recessive ones in the other LB were already checked above
+	for _, m := range other.mutn {
+		switch m.Type {
+		case LETHAL_DOMINANT:
+			lethal = true
+		case STERILE_DOMINANT:
+			sterile = true
+		}
+	}
+	return
+}
+
+
+// LethalSterileStatus returns whether this chromosome and the other one of the pair contain a lethal or sterility mutation that takes effect.
+func (c *Chromosome) LethalSterileStatus(other *Chromosome) (lethal, sterile bool) {
+	for i := range c.LinkageBlocks {
+		l, s := c.LinkageBlocks[i].LethalSterileStatus(&other.LinkageBlocks[i])
+		lethal = lethal || l
+		sterile = sterile || s
+		if lethal && sterile { return }
+	}
+	return
+}
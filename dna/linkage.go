@@ -17,12 +17,13 @@ type LinkageBlock struct {
 	mutn []Mutation		// holds deleterious, neutral, favorable, initial deleterious, initial favorable
 	// Note: instead of adding the space of another LB member var, we could always make sure the mutn array is barely big enough so the builtin append() would naturally copy it
 	IsPtrToParent bool		// whether or not the mutn slice is still a reference to its parents mutn array. We don't copy it until we add a mutation. During create of a new LB, this will naturally be set to false.
-	fitnessEffect float32
 	numDeleterious         uint16
 	numFavorable           uint16
 	numNeutrals            uint16               // this is used instead of the array above if track_neutrals==false
 	numDelAllele uint16
 	numFavAllele uint16
+	fitnessEffect float32
+	traitValues *[]float32		// the sum of the effects of the trait mutations in this LB on each trait, or nil if it has none. Shared with the parent LB, so never modified in place. A ptr so the LB stays small.
 }
 
 
@@ -39,7 +40,7 @@ func (lb *LinkageBlock) AppendMutation(mutId uint64, uniformRandom *rand.Rand) (
 	case DELETERIOUS_DOMINANT:
 		fallthrough
 	case DELETERIOUS_RECESSIVE:
-		fitnessEffect = calcDelMutationAttrs(mType, uniformRandom)
+		mType, fitnessEffect = calcDelMutationAttrs(mType, uniformRandom)
 		if config.Cfg.Computation.Tracking_threshold == 0.0 || fitnessEffect < -config.Cfg.Computation.Tracking_threshold {
 			// We are tracking this mutation, so create it and append
 			lb.appendMutn(Mutation{Id: mutId, Type: mType, FitnessEffect: fitnessEffect})
@@ -54,13 +55,34 @@ func (lb *LinkageBlock) AppendMutation(mutId uint64, uniformRandom *rand.Rand) (
 	case FAVORABLE_DOMINANT:
 		fallthrough
 	case FAVORABLE_RECESSIVE:
-		fitnessEffect = calcFavMutationAttrs(mType, uniformRandom)
+		mType, fitnessEffect = calcFavMutationAttrs(mType, uniformRandom)
 		if config.Cfg.Computation.Tracking_threshold == 0.0 || fitnessEffect > config.Cfg.Computation.Tracking_threshold {
 			// We are tracking this mutation, so create it and append
 			lb.appendMutn(Mutation{Id: mutId, Type: mType, FitnessEffect: fitnessEffect})
 		}
 		lb.numFavorable++
 		lb.fitnessEffect += fitnessEffect	// currently only the additive combination model is supported, so this is appropriate
+	case LETHAL_DOMINANT, LETHAL_RECESSIVE, STERILE_DOMINANT, STERILE_RECESSIVE:
+		// These are always tracked, because we need to find them to know their effect. They count as deleterious, but have no fitness effect.
+		lb.appendMutn(Mutation{Id: mutId, Type: mType})
+		lb.numDeleterious++
+	case TRAIT:
+		// These have no direct fitness effect, so are counted with the neutrals. The effect on the 1st trait is stored in FitnessEffect.
+		traitEffects := calcTraitEffects(uniformRandom)
+		if config.Cfg.Computation.Tracking_threshold == 0.0 || traitEffects[0] > config.Cfg.Computation.Tracking_threshold || traitEffects[0] < -config.Cfg.Computation.Tracking_threshold {
+			lb.appendMutn(Mutation{Id: mutId, Type: mType, FitnessEffect: traitEffects[0]})
+		}
+		lb.numNeutrals++
+		lb.addTraitEffects(traitEffects)
+	case MUTATOR:
+		// These are always tracked, because we need to find them to calculate the mutation rate of the carrier. They have no direct fitness effect, so are counted with the neutrals.
+		lb.appendMutn(Mutation{Id: mutId, Type: mType, FitnessEffect: float32(uniformRandom.NormFloat64() * config.Cfg.Mutations.Mutator_effect_sd)})
+		lb.numNeutrals++
+	case LOCAL_ADAPTATION:
+		// These are always tracked, because their effect depends on the tribe of the carrier. They have no fixed fitness effect, so are counted with the neutrals.
+		_, benefit := calcFavMutationAttrs(FAVORABLE_DOMINANT, uniformRandom)
+		lb.appendMutn(Mutation{Id: mutId, Type: mType, FitnessEffect: benefit})
+		lb.numNeutrals++
 	}
 	return
 }
@@ -133,6 +155,54 @@ func (lb *LinkageBlock) SumFitness() (fitness float32) {
 }
 
 
+// TraitValues returns the combined effect of all of the trait mutations in this LB on each quantitative trait, or nil if there are none
+func (lb *LinkageBlock) TraitValues() []float32 {
+	if lb.traitValues == nil { return nil }
+	return *lb.traitValues
+}
+
+
+// addTraitEffects adds the effects of a new trait mutation to the trait values of this LB. The trait values slice may be shared with the parent LB, so we always make a new one.
+func (lb *LinkageBlock) addTraitEffects(traitEffects []float32) {
+	newValues := make([]float32, len(traitEffects))
+	if lb.traitValues != nil { copy(newValues, *lb.traitValues) }
+	for i := range newValues { newValues[i] += traitEffects[i] }
+	lb.traitValues = &newValues
+}
+
+
+// HomozygousCorrection returns the amount the fitness of an individual must be adjusted by for the mutations that are in both this LB and
+// the other LB of the pair (the same LB index on the chromosome from the other parent). The LBs' fitness includes the heterozygous effect h*s
+// of each of these mutations twice, so the correction is what changes that to the full effect s: h*s*(1/h - 2).
+func (lb *LinkageBlock) HomozygousCorrection(other *LinkageBlock) (correction float64) {
+	if len(lb.mutn) == 0 || len(other.mutn) == 0 { return }
+	// Put the ids of the other LB in a set, so this is linear in the number of mutations instead of quadratic
+	otherIds := make(map[uint64]struct{}, len(other.mutn))
+	for _, o := range other.mutn { otherIds[o.Id] = struct{}{} }
+	for _, m := range lb.mutn {
+		if m.FitnessEffect == 0.0 { continue }
+		h := HeteroExpression(m.Type, m.FitnessEffect)
+		if h <= 0.0 || h == 0.5 { continue }		// co-dominant mutations need no correction
+		if _, ok := otherIds[m.Id]; ok { correction += float64(m.FitnessEffect) * (1.0/h - 2.0) }
+	}
+	return
+}
+
+
+// HemizygousCorrection returns the amount the fitness of an individual must be adjusted by when this LB is the only expressed copy (e.g. in an
+// imprinted region). The LB's fitness includes only the heterozygous effect h*s of each of its mutations, so the correction is what changes
+// that to the full effect s: h*s*(1/h - 1). Only tracked mutations are in the mutn slice, so this requires tracking_threshold=0.
+func (lb *LinkageBlock) HemizygousCorrection() (correction float64) {
+	for _, m := range lb.mutn {
+		if m.FitnessEffect == 0.0 { continue }
+		h := HeteroExpression(m.Type, m.FitnessEffect)
+		if h <= 0.0 { continue }		// the mutation types that do not affect fitness through the LB
+		correction += float64(m.FitnessEffect) * (1.0/h - 1.0)
+	}
+	return
+}
+
+
 // GetMutationStats returns the number of deleterious, neutral, favorable mutations, and deleterious and favorable initial alleles.
 func (lb *LinkageBlock) GetMutationStats() (deleterious, neutral, favorable, delAllele, favAllele uint32) {
 	// Note: this is only valid for the additive combination method
@@ -222,6 +292,8 @@ func (lb *LinkageBlock) CountAlleles(allelesForThisIndiv *AlleleCount) {
 			} else {
 				allelesForThisIndiv.FavInitialAlleles[id] = Allele{Count: 1, FitnessEffect: m.FitnessEffect}
 			}
+		case LETHAL_DOMINANT, LETHAL_RECESSIVE, STERILE_DOMINANT, STERILE_RECESSIVE, TRAIT, MUTATOR, LOCAL_ADAPTATION:
+			// These have no fitness effect, so are not included in the allele outputs, which are organized by fitness effect
 		default:
 			log.Fatalf("Error: unknown Mutation type %v found when counting alleles.", m.Type)
 		}
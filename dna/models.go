@@ -11,6 +11,18 @@ const (
 	FIXED_FITNESS_EFFECT MutationFitnessModelType = "fixed"
 	UNIFORM_FITNESS_EFFECT MutationFitnessModelType = "uniform"
 	WEIBULL_FITNESS_EFFECT MutationFitnessModelType = "weibull"
+	GAMMA_FITNESS_EFFECT MutationFitnessModelType = "gamma"
+	REFLECTED_GAMMA_FITNESS_EFFECT MutationFitnessModelType = "reflected-gamma"
+	LOGNORMAL_FITNESS_EFFECT MutationFitnessModelType = "lognormal"
+	EXPONENTIAL_FITNESS_EFFECT MutationFitnessModelType = "exponential"
+	MIXTURE_FITNESS_EFFECT MutationFitnessModelType = "mixture"
+	EMPIRICAL_FITNESS_EFFECT MutationFitnessModelType = "empirical"
+)
+
+type DominanceModelType string
+const (
+	FIXED_DOMINANCE DominanceModelType = "fixed"
+	HS_RELATIONSHIP_DOMINANCE DominanceModelType = "hs-relationship"
 )
 
 type CrossoverModelType string
@@ -25,8 +37,13 @@ const (
 type Models struct {
 	CalcDelMutationFitness CalcMutationFitnessType
 	CalcFavMutationFitness CalcMutationFitnessType
+	DelFitnessLogCDF CalcFitnessLogCDFType		// these go with CalcDelMutationFitness and CalcFavMutationFitness
+	FavFitnessLogCDF CalcFitnessLogCDFType
+	CalcHeteroExpression CalcHeteroExpressionType
+	DominanceFromFitness bool		// true if the dominance model determines whether a mutation is dominant or recessive from its fitness effect, instead of from fraction_recessive
 	Crossover CrossoverType
 	CalcAlleleFitness CalcAlleleFitnessType		// this goes with pop.InitialAlleleModelType
+	RecordTreeSequence bool		// true if the genealogy of the chromosomes is recorded, because the tree-sequence dir is being output
 }
 
 // Mdl is the singleton instance of Models that can be accessed throughout the dna package. It gets set in SetModels().
@@ -46,20 +63,89 @@ func SetModels(c *config.Config) {
 		if c.Mutations.Uniform_fitness_effect_fav == 0.0 { log.Fatal("Error: if fitness_effect_model==fixed, you must set uniform_fitness_effect_fav to a non-zero value.") }
 		Mdl.CalcFavMutationFitness = CalcFixedFavMutationFitness
 		mdlNames = append(mdlNames, "CalcFixedFavMutationFitness")
+		Mdl.DelFitnessLogCDF, Mdl.FavFitnessLogCDF = WeibullDelFitnessLogCDF, WeibullFavFitnessLogCDF
 	case UNIFORM_FITNESS_EFFECT:
 		Mdl.CalcDelMutationFitness = CalcUniformDelMutationFitness
 		mdlNames = append(mdlNames, "CalcUniformDelMutationFitness")
 		Mdl.CalcFavMutationFitness = CalcUniformFavMutationFitness
 		mdlNames = append(mdlNames, "CalcUniformFavMutationFitness")
+		Mdl.DelFitnessLogCDF, Mdl.FavFitnessLogCDF = UniformDelFitnessLogCDF, UniformFavFitnessLogCDF
 	case WEIBULL_FITNESS_EFFECT:
 		Mdl.CalcDelMutationFitness = CalcWeibullDelMutationFitness
 		mdlNames = append(mdlNames, "CalcWeibullDelMutationFitness")
 		Mdl.CalcFavMutationFitness = CalcWeibullFavMutationFitness
 		mdlNames = append(mdlNames, "CalcWeibullFavMutationFitness")
+		Mdl.DelFitnessLogCDF, Mdl.FavFitnessLogCDF = WeibullDelFitnessLogCDF, WeibullFavFitnessLogCDF
+	case GAMMA_FITNESS_EFFECT:
+		checkDfeMeans(c)
+		if c.Mutations.Dfe_gamma_shape <= 0.0 { log.Fatal("Error: if fitness_effect_model==gamma, dfe_gamma_shape must be > 0.0") }
+		Mdl.CalcDelMutationFitness = CalcGammaDelMutationFitness
+		mdlNames = append(mdlNames, "CalcGammaDelMutationFitness")
+		Mdl.CalcFavMutationFitness = CalcGammaFavMutationFitness
+		mdlNames = append(mdlNames, "CalcGammaFavMutationFitness")
+		Mdl.DelFitnessLogCDF, Mdl.FavFitnessLogCDF = survivalDel(gammaSurvival(c.Mutations.Dfe_mean_del)), survivalFav(gammaSurvival(c.Mutations.Dfe_mean_fav))
+	case REFLECTED_GAMMA_FITNESS_EFFECT:
+		checkDfeMeans(c)
+		if c.Mutations.Dfe_gamma_shape <= 0.0 { log.Fatal("Error: if fitness_effect_model==reflected-gamma, dfe_gamma_shape must be > 0.0") }
+		Mdl.CalcDelMutationFitness = CalcGammaDelMutationFitness
+		mdlNames = append(mdlNames, "CalcGammaDelMutationFitness")
+		Mdl.CalcFavMutationFitness = CalcReflectedGammaFavMutationFitness
+		mdlNames = append(mdlNames, "CalcReflectedGammaFavMutationFitness")
+		Mdl.DelFitnessLogCDF, Mdl.FavFitnessLogCDF = survivalDel(gammaSurvival(c.Mutations.Dfe_mean_del)), survivalFav(gammaSurvival(c.Mutations.Dfe_mean_del))
+	case LOGNORMAL_FITNESS_EFFECT:
+		checkDfeMeans(c)
+		if c.Mutations.Dfe_lognormal_sigma <= 0.0 { log.Fatal("Error: if fitness_effect_model==lognormal, dfe_lognormal_sigma must be > 0.0") }
+		Mdl.CalcDelMutationFitness = CalcLognormalDelMutationFitness
+		mdlNames = append(mdlNames, "CalcLognormalDelMutationFitness")
+		Mdl.CalcFavMutationFitness = CalcLognormalFavMutationFitness
+		mdlNames = append(mdlNames, "CalcLognormalFavMutationFitness")
+		Mdl.DelFitnessLogCDF, Mdl.FavFitnessLogCDF = survivalDel(lognormalSurvival(c.Mutations.Dfe_mean_del)), survivalFav(lognormalSurvival(c.Mutations.Dfe_mean_fav))
+	case EXPONENTIAL_FITNESS_EFFECT:
+		checkDfeMeans(c)
+		Mdl.CalcDelMutationFitness = CalcExponentialDelMutationFitness
+		mdlNames = append(mdlNames, "CalcExponentialDelMutationFitness")
+		Mdl.CalcFavMutationFitness = CalcExponentialFavMutationFitness
+		mdlNames = append(mdlNames, "CalcExponentialFavMutationFitness")
+		Mdl.DelFitnessLogCDF, Mdl.FavFitnessLogCDF = survivalDel(exponentialSurvival(c.Mutations.Dfe_mean_del)), survivalFav(exponentialSurvival(c.Mutations.Dfe_mean_fav))
+	case MIXTURE_FITNESS_EFFECT:
+		checkDfeMeans(c)
+		var err error
+		if mixture, err = parseMixture(c.Mutations.Dfe_mixture); err != nil { log.Fatalf("Error: %v", err) }
+		Mdl.CalcDelMutationFitness = CalcMixtureDelMutationFitness
+		mdlNames = append(mdlNames, "CalcMixtureDelMutationFitness")
+		Mdl.CalcFavMutationFitness = CalcMixtureFavMutationFitness
+		mdlNames = append(mdlNames, "CalcMixtureFavMutationFitness")
+		Mdl.DelFitnessLogCDF, Mdl.FavFitnessLogCDF = MixtureDelFitnessLogCDF, MixtureFavFitnessLogCDF
+	case EMPIRICAL_FITNESS_EFFECT:
+		if c.Mutations.Dfe_empirical_file == "" { log.Fatal("Error: if fitness_effect_model==empirical, you must set dfe_empirical_file.") }
+		if err := ReadEmpiricalDfe(c.Mutations.Dfe_empirical_file); err != nil { log.Fatalf("Error: %v", err) }
+		Mdl.CalcDelMutationFitness = CalcEmpiricalDelMutationFitness
+		mdlNames = append(mdlNames, "CalcEmpiricalDelMutationFitness")
+		Mdl.CalcFavMutationFitness = CalcEmpiricalFavMutationFitness
+		mdlNames = append(mdlNames, "CalcEmpiricalFavMutationFitness")
+		Mdl.DelFitnessLogCDF, Mdl.FavFitnessLogCDF = survivalDel(empiricalDel.survival), survivalFav(empiricalFav.survival)
 	default:
 		log.Fatalf("Error: unrecognized value for fitness_effect_model: %v", c.Mutations.Fitness_effect_model)
 	}
 
+	if c.Population.Ploidy == 1 {
+		// Haploids have only 1 copy of each mutation, so the dominance model does not apply
+		Mdl.CalcHeteroExpression = FullHeteroExpression
+		mdlNames = append(mdlNames, "FullHeteroExpression")
+	} else {
+		switch DominanceModelType(strings.ToLower(c.Mutations.Dominance_model)) {
+		case FIXED_DOMINANCE:
+			Mdl.CalcHeteroExpression = FixedHeteroExpression
+			mdlNames = append(mdlNames, "FixedHeteroExpression")
+		case HS_RELATIONSHIP_DOMINANCE:
+			Mdl.CalcHeteroExpression = HsHeteroExpression
+			Mdl.DominanceFromFitness = true
+			mdlNames = append(mdlNames, "HsHeteroExpression")
+		default:
+			log.Fatalf("Error: unrecognized value for dominance_model: %v", c.Mutations.Dominance_model)
+		}
+	}
+
 	switch CrossoverModelType(strings.ToLower(c.Population.Crossover_model)) {
 	case NO_CROSSOVER:
 		Mdl.Crossover = NoCrossover
@@ -74,5 +160,17 @@ func SetModels(c *config.Config) {
 		log.Fatalf("Error: unrecognized value for crossover_model: %v", c.Population.Crossover_model)
 	}
 
+	if config.FMgr.IsDir(config.TREE_SEQUENCE_DIRECTORY) {
+		Mdl.RecordTreeSequence = true
+		mdlNames = append(mdlNames, "RecordTreeSequence")
+	}
+
 	config.Verbose(1, "Running with these dna models: %v", strings.Join(mdlNames, ", "))
 }
+
+
+// checkDfeMeans verifies the config params used by the fitness effect models that are parameterized by their mean
+func checkDfeMeans(c *config.Config) {
+	if c.Mutations.Dfe_mean_del <= 0.0 || c.Mutations.Dfe_mean_del > 1.0 { log.Fatalf("Error: if fitness_effect_model==%v, dfe_mean_del must be > 0.0 and <= 1.0", c.Mutations.Fitness_effect_model) }
+	if c.Mutations.Dfe_mean_fav <= 0.0 { log.Fatalf("Error: if fitness_effect_model==%v, dfe_mean_fav must be > 0.0", c.Mutations.Fitness_effect_model) }
+}
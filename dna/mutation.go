@@ -19,6 +19,13 @@ const (
 	FAVORABLE_RECESSIVE MutationType = iota
 	DEL_ALLELE MutationType = iota  // Note: for now we assume that all initial contrasting alleles are co-dominant, so we don't have to store dominant/recessive
 	FAV_ALLELE MutationType = iota
+	LETHAL_DOMINANT MutationType = iota		// lethal and sterility mutations have no fitness effect, they are handled by LethalSterileStatus()
+	LETHAL_RECESSIVE MutationType = iota
+	STERILE_DOMINANT MutationType = iota
+	STERILE_RECESSIVE MutationType = iota
+	TRAIT MutationType = iota		// affects the quantitative trait under stabilizing selection instead of fitness. Its FitnessEffect is the effect on the trait.
+	MUTATOR MutationType = iota		// changes the mutation rate of its carrier instead of fitness. Its FitnessEffect is the natural log of the factor it multiplies the mutation rate by.
+	LOCAL_ADAPTATION MutationType = iota		// beneficial in the environment of 1 tribe and deleterious or neutral in the others. Its FitnessEffect is the benefit in its home tribe.
 )
 
 
@@ -83,23 +90,35 @@ func AlleleCountFactory() *AlleleCount {
 // This is used by the LB to determine which of the Mutation subclasses to create.
 func CalcMutationType(uniformRandom *rand.Rand) (mType MutationType) {
 
+	// Determine if this mutation affects the quantitative trait. Check the fraction first so we don't use up random numbers when there is no trait.
+	if config.Cfg.Mutations.Fraction_trait_mutn > 0.0 && uniformRandom.Float64() < config.Cfg.Mutations.Fraction_trait_mutn { return TRAIT }
+
+	// Determine if this mutation is a mutator. Check the fraction first so we don't use up random numbers when mutators are not being modeled.
+	if config.Cfg.Mutations.Fraction_mutator > 0.0 && uniformRandom.Float64() < config.Cfg.Mutations.Fraction_mutator { return MUTATOR }
+
+	// Determine if this mutation is locally adapted. Check the fraction first so we don't use up random numbers when local adaptation is not being modeled.
+	if config.Cfg.Mutations.Fraction_local_adaptation > 0.0 && uniformRandom.Float64() < config.Cfg.Mutations.Fraction_local_adaptation { return LOCAL_ADAPTATION }
+
 	// Determine if this mutation is deleterious, neutral, or favorable.
 	// Frac_fav_mutn is the fraction of the non-neutral mutations that are favorable.
 	rnd := uniformRandom.Float64()
+	// Determine if it is dominant or recessive, unless the dominance model will determine that later from the fitness effect (in which case calcDelMutationAttrs() and calcFavMutationAttrs() reclassify it).
 	if rnd < config.Cfg.Mutations.Frac_fav_mutn * (1.0 - config.Cfg.Mutations.Fraction_neutral) {
-		dominant := config.Cfg.Mutations.Fraction_recessive < uniformRandom.Float64()
+		dominant := Mdl.DominanceFromFitness || config.Cfg.Mutations.Fraction_recessive < uniformRandom.Float64()
 		if dominant {
 			mType = FAVORABLE_DOMINANT
 		} else {
 			mType = FAVORABLE_RECESSIVE
 		}
 	} else if rnd < 1.0 - config.Cfg.Mutations.Fraction_neutral {
-		dominant := config.Cfg.Mutations.Fraction_recessive < uniformRandom.Float64()
+		dominant := Mdl.DominanceFromFitness || config.Cfg.Mutations.Fraction_recessive < uniformRandom.Float64()
 		if dominant {
 			mType = DELETERIOUS_DOMINANT
 		} else {
 			mType = DELETERIOUS_RECESSIVE
 		}
+		// Check the fractions first so we don't use up random numbers when lethal and sterility mutations are not being modeled
+		if config.Cfg.Mutations.Fraction_lethal > 0.0 || config.Cfg.Mutations.Fraction_sterile > 0.0 { mType = calcLethalSterileType(mType, uniformRandom) }
 	} else {
 		mType = NEUTRAL
 	}
@@ -107,35 +126,94 @@ func CalcMutationType(uniformRandom *rand.Rand) (mType MutationType) {
 }
 
 
+// calcTraitEffects returns the effects of a new trait mutation on each of the quantitative traits. They are drawn from a multivariate normal distribution
+// with mean 0, standard deviation trait_mutn_sd, and the correlation trait_correlation between each pair of traits.
+func calcTraitEffects(uniformRandom *rand.Rand) []float32 {
+	chol := config.Computed.Trait_cholesky
+	normals := make([]float64, len(chol))
+	for i := range normals { normals[i] = uniformRandom.NormFloat64() }
+	effects := make([]float32, len(chol))
+	for i := range chol {
+		var sum float64
+		for j := range chol[i] { sum += chol[i][j] * normals[j] }
+		effects[i] = float32(sum * config.Cfg.Mutations.Trait_mutn_sd)
+	}
+	return effects
+}
+
+
+// HeteroExpression returns the factor that the full fitness effect of a mutation was multiplied by when the mutation was created
+// (the degree of dominance, h), given its type and its stored (heterozygous) fitness effect. Initial contrasting alleles are co-dominant. Returns 0 for neutral mutations.
+func HeteroExpression(mType MutationType, fitnessEffect float32) float64 {
+	switch mType {
+	case DELETERIOUS_DOMINANT, FAVORABLE_DOMINANT, DELETERIOUS_RECESSIVE, FAVORABLE_RECESSIVE:
+		if Mdl.DominanceFromFitness {
+			// The stored effect is e = s / (2 + k|s|), so solving for h = e/s gives (|e| can not reach 1/k, but a fitness bin boundary can):
+			return math.Max(0.0, (1.0 - config.Cfg.Mutations.Dominance_hs_k * math.Abs(float64(fitnessEffect))) / 2.0)
+		}
+		return Mdl.CalcHeteroExpression(mType, 0.0)		// the fixed models do not depend on the fitness effect
+	case DEL_ALLELE, FAV_ALLELE:
+		return 0.5
+	}
+	return 0.0
+}
+
+
+// These are the different algorithms for determining the degree of dominance (h) of a new mutation, given the full (homozygous) fitness effect s of it.
+// A pointer to 1 of them is chosen at initialization time.
+type CalcHeteroExpressionType func(mType MutationType, s float64) float64
+
+// FixedHeteroExpression uses dominant_hetero_expression or recessive_hetero_expression, according to whether the mutation was chosen to be dominant or recessive.
+func FixedHeteroExpression(mType MutationType, _ float64) float64 {
+	if mType == DELETERIOUS_DOMINANT || mType == FAVORABLE_DOMINANT { return config.Cfg.Mutations.Dominant_hetero_expression }
+	return config.Cfg.Mutations.Recessive_hetero_expression
+}
+
+// HsHeteroExpression makes h decrease as the size of the fitness effect increases: h = 1/(2 + k|s|). So mutations with tiny effects are nearly co-dominant
+// and mutations with large effects are nearly recessive, which is what empirical data show.
+func HsHeteroExpression(_ MutationType, s float64) float64 {
+	return 1.0 / (2.0 + config.Cfg.Mutations.Dominance_hs_k * math.Abs(s))
+}
+
+// FullHeteroExpression is used for haploids, where every mutation gets its full fitness effect
+func FullHeteroExpression(_ MutationType, _ float64) float64 { return 1.0 }
+
+
 // calcDelMutationAttrs determines the attributes of a new mutation, based on a random number and the config params.
 // This is used in the subclass factory to initialize the base Mutation class members, and in LB AppendMutation() if it is untracked.
+// If the dominance model determines dominance from the fitness effect, the returned type is the reclassified type.
 //func calcDelMutationAttrs(uniformRandom *rand.Rand) (fitnessEffect float32) {
-func calcDelMutationAttrs(mType MutationType, uniformRandom *rand.Rand) (fitnessEffect float32) {
-	// Determine if this mutation is dominant or recessive and use that to calc the fitness
-	//dominant := config.Cfg.Mutations.Fraction_recessive < uniformRandom.Float64()
-	if mType == DELETERIOUS_DOMINANT {
-		fitnessEffect = float32(Mdl.CalcDelMutationFitness(uniformRandom) * config.Cfg.Mutations.Dominant_hetero_expression)
-	} else {
-		fitnessEffect = float32(Mdl.CalcDelMutationFitness(uniformRandom) * config.Cfg.Mutations.Recessive_hetero_expression)
+func calcDelMutationAttrs(mType MutationType, uniformRandom *rand.Rand) (MutationType, float32) {
+	// Use the dominance model to calc the fitness effect in the heterozygous state
+	s := Mdl.CalcDelMutationFitness(uniformRandom)
+	h := Mdl.CalcHeteroExpression(mType, s)
+	if Mdl.DominanceFromFitness {
+		if h < 0.5 {
+			mType = DELETERIOUS_RECESSIVE
+		} else {
+			mType = DELETERIOUS_DOMINANT
+		}
 	}
-
-	return
+	return mType, float32(s * h)
 }
 
 
 // calcFavMutationAttrs determines the attributes of a new mutation, based on a random number and the config params.
 // This is used in the subclass factory to initialize the base Mutation class members, and in LB AppendMutation() if it is untracked.
+// If the dominance model determines dominance from the fitness effect, the returned type is the reclassified type.
 //func calcFavMutationAttrs(uniformRandom *rand.Rand) (fitnessEffect float32) {
-func calcFavMutationAttrs(mType MutationType, uniformRandom *rand.Rand) (fitnessEffect float32) {
-	// Determine if this mutation is dominant or recessive and use that to calc the fitness
-	//dominant := config.Cfg.Mutations.Fraction_recessive < uniformRandom.Float64()
-	if mType == FAVORABLE_DOMINANT {
-		fitnessEffect = float32(Mdl.CalcFavMutationFitness(uniformRandom) * config.Cfg.Mutations.Dominant_hetero_expression)
-	} else {
-		fitnessEffect = float32(Mdl.CalcFavMutationFitness(uniformRandom) * config.Cfg.Mutations.Recessive_hetero_expression)
+func calcFavMutationAttrs(mType MutationType, uniformRandom *rand.Rand) (MutationType, float32) {
+	// Use the dominance model to calc the fitness effect in the heterozygous state
+	s := Mdl.CalcFavMutationFitness(uniformRandom)
+	h := Mdl.CalcHeteroExpression(mType, s)
+	if Mdl.DominanceFromFitness {
+		if h < 0.5 {
+			mType = FAVORABLE_RECESSIVE
+		} else {
+			mType = FAVORABLE_DOMINANT
+		}
 	}
-
-	return
+	return mType, float32(s * h)
 }
 
 
@@ -173,6 +251,15 @@ func CalcWeibullFavMutationFitness(uniformRandom *rand.Rand) float64 {
 	return config.Cfg.Mutations.Max_fav_fitness_gain * math.Exp(-config.Computed.Alpha_fav * math.Pow(uniformRandom.Float64(), config.Computed.Gamma_fav))
 }
 
+// The reference curves for weibull, from diagnostics.f90. These are also used for fitness_effect_model==fixed, because a single effect value
+// does not give a useful curve to normalize by.
+func WeibullDelFitnessLogCDF(y float64) float64 { return math.Pow(y / config.Computed.Alpha_del, 1. / config.Computed.Gamma_del) }
+func WeibullFavFitnessLogCDF(y float64) float64 { return math.Pow(y / config.Computed.Alpha_fav, 1. / config.Computed.Gamma_fav) }
+
+// The reference curves for uniform: the fraction of the range 0 - uniform_fitness_effect_* that is >= the effect
+func UniformDelFitnessLogCDF(y float64) float64 { return math.Max(0.0, 1.0 - math.Exp(-y) / config.Cfg.Mutations.Uniform_fitness_effect_del) }
+func UniformFavFitnessLogCDF(y float64) float64 { return math.Max(0.0, 1.0 - config.Cfg.Mutations.Max_fav_fitness_gain * math.Exp(-y) / config.Cfg.Mutations.Uniform_fitness_effect_fav) }
+
 
 // These are the different algorithms for assigning a fitness factor to an initial allele. Pointers to 2 of them are chosen at initialization time.
 type CalcAlleleFitnessType func(uniformRandom *rand.Rand) float64
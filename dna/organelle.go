@@ -0,0 +1,57 @@
+package dna
+
+import (
+	"math/rand"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+)
+
+// The organelle genome (e.g. mitochondria) is haploid, non-recombining, and inherited from only 1 parent. It uses the Chromosome and LinkageBlock
+// types, but its mutations come from the organelle config params, and since there is no other copy of it, they are always fully expressed.
+
+// calcOrganelleMutationType determines if the next organelle mutation should be deleterious/neutral/favorable based on a random number and the organelle mutation rates.
+func calcOrganelleMutationType(uniformRandom *rand.Rand) MutationType {
+	rnd := uniformRandom.Float64()
+	if rnd < config.Cfg.Organelle.Organelle_frac_fav_mutn * (1.0 - config.Cfg.Organelle.Organelle_fraction_neutral) {
+		return FAVORABLE_DOMINANT
+	} else if rnd < 1.0 - config.Cfg.Organelle.Organelle_fraction_neutral {
+		return DELETERIOUS_DOMINANT
+	}
+	return NEUTRAL
+}
+
+
+// appendOrganelleMutation creates and adds an organelle mutation to this LB.
+func (lb *LinkageBlock) appendOrganelleMutation(mutId uint64, uniformRandom *rand.Rand) (mType MutationType, fitnessEffect float32) {
+	mType = calcOrganelleMutationType(uniformRandom)
+	switch mType {
+	case DELETERIOUS_DOMINANT:
+		fitnessEffect = float32(Mdl.CalcDelMutationFitness(uniformRandom) * config.Cfg.Organelle.Organelle_fitness_effect_scale)
+		if config.Cfg.Computation.Tracking_threshold == 0.0 || fitnessEffect < -config.Cfg.Computation.Tracking_threshold {
+			lb.appendMutn(Mutation{Id: mutId, Type: mType, FitnessEffect: fitnessEffect})
+		}
+		lb.numDeleterious++
+	case NEUTRAL:
+		if config.Cfg.Computation.Track_neutrals {
+			lb.appendMutn(Mutation{Id: mutId, Type: NEUTRAL})
+		}
+		lb.numNeutrals++
+	case FAVORABLE_DOMINANT:
+		fitnessEffect = float32(Mdl.CalcFavMutationFitness(uniformRandom) * config.Cfg.Organelle.Organelle_fitness_effect_scale)
+		if config.Cfg.Computation.Tracking_threshold == 0.0 || fitnessEffect > config.Cfg.Computation.Tracking_threshold {
+			lb.appendMutn(Mutation{Id: mutId, Type: mType, FitnessEffect: fitnessEffect})
+		}
+		lb.numFavorable++
+	}
+	lb.fitnessEffect += fitnessEffect
+	return
+}
+
+
+// AppendOrganelleMutation creates an organelle mutation in the specified LB of this organelle genome
+func (c *Chromosome) AppendOrganelleMutation(lbIndex int, mutId uint64, uniformRandom *rand.Rand) MutationType {
+	mType, fitnessEffect := c.LinkageBlocks[lbIndex].appendOrganelleMutation(mutId, uniformRandom)
+	c.FitnessEffect += fitnessEffect
+	return mType
+}
+
@@ -0,0 +1,40 @@
+package dna
+
+import (
+	"math"
+)
+
+// DosageExpression returns the fraction of the full fitness effect of a mutation that is expressed when it is in dosage of the ploidy copies of
+// a chromosome. It is (dosage/ploidy)^(-log2(h)), where h is the expression of the mutation in a diploid heterozygote, so for a diploid it gives h
+// for 1 copy and 1 for 2 copies, and for a co-dominant mutation (h=0.5) it is additive in any ploidy.
+func DosageExpression(h float64, dosage, ploidy int) float64 {
+	return math.Pow(float64(dosage) / float64(ploidy), -math.Log2(h))
+}
+
+
+// DosageCorrection returns the amount the fitness of an individual must be adjusted by for the tracked mutations on these homologous chromosomes.
+// The chromosomes' fitness includes the heterozygous effect h*s of each copy of a mutation, so the correction is what changes that to the dosage
+// based effect: s * DosageExpression(h, d, ploidy) - d*h*s.
+func DosageCorrection(homologs []*Chromosome) (correction float64) {
+	ploidy := len(homologs)
+	for i := range homologs[0].LinkageBlocks {
+		for j, chr := range homologs {
+			for _, m := range chr.LinkageBlocks[i].mutn {
+				if m.FitnessEffect == 0.0 { continue }
+				h := HeteroExpression(m.Type, m.FitnessEffect)
+				if h <= 0.0 { continue }
+				// Only count each mutation the 1st time we find it
+				alreadyCounted := false
+				for k := 0; k < j && !alreadyCounted; k++ { alreadyCounted = homologs[k].LinkageBlocks[i].hasMutation(m.Id) }
+				if alreadyCounted { continue }
+				dosage := 1
+				for k := j + 1; k < ploidy; k++ {
+					if homologs[k].LinkageBlocks[i].hasMutation(m.Id) { dosage++ }
+				}
+				s := float64(m.FitnessEffect) / h
+				correction += s * DosageExpression(h, dosage, ploidy) - float64(dosage) * float64(m.FitnessEffect)
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,53 @@
+package dna
+
+// Segment is a contiguous range of LBs [Left, Right) of a chromosome that was inherited from the genome (tree sequence node) Parent.
+type Segment struct {
+	Left, Right uint32
+	Parent int32
+}
+
+// NewMutationRecord is a mutation that was added to a chromosome during mating, saved so it can be added to the tree sequence.
+type NewMutationRecord struct {
+	LB uint32
+	Type MutationType
+	Id uint64
+}
+
+
+// recordSegment notes that LB lbIndex of this chromosome came from the genome parentNode, extending the last segment if it is contiguous with it.
+func (c *Chromosome) recordSegment(parentNode int32, lbIndex int) {
+	lb := uint32(lbIndex)
+	if last := len(c.Segments) - 1; last >= 0 && c.Segments[last].Parent == parentNode && c.Segments[last].Right == lb {
+		c.Segments[last].Right = lb + 1
+		return
+	}
+	c.Segments = append(c.Segments, Segment{Left: lb, Right: lb + 1, Parent: parentNode})
+}
+
+
+// ClearTreeSequenceRecords releases the segments and new mutations of this chromosome once they have been added to the tree sequence.
+func (c *Chromosome) ClearTreeSequenceRecords() {
+	c.Segments = nil
+	c.NewMutations = nil
+}
+
+
+// DerivedState returns the code written as the derived state of a mutation of this type in the tree sequence mutation table.
+// Upper case is dominant and lower case is recessive.
+func (mType MutationType) DerivedState() string {
+	switch mType {
+	case DELETERIOUS_DOMINANT: return "D"
+	case DELETERIOUS_RECESSIVE: return "d"
+	case NEUTRAL: return "N"
+	case FAVORABLE_DOMINANT: return "F"
+	case FAVORABLE_RECESSIVE: return "f"
+	case LETHAL_DOMINANT: return "L"
+	case LETHAL_RECESSIVE: return "l"
+	case STERILE_DOMINANT: return "S"
+	case STERILE_RECESSIVE: return "s"
+	case TRAIT: return "T"
+	case MUTATOR: return "M"
+	case LOCAL_ADAPTATION: return "E"
+	}
+	return "A"		// initial alleles are not recorded, but just in case
+}
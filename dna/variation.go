@@ -0,0 +1,46 @@
+package dna
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+)
+
+// CreateStandingVariant draws a mutation from the mutation model to be part of the standing variation of the genesis population. Returns
+// ok==false for mutations that would not be segregating at neutral frequencies: lethal, sterility, trait, and mutator mutations, and those whose
+// expressed fitness effect is larger in magnitude than maxEffect.
+func CreateStandingVariant(mutId uint64, maxEffect float64, uniformRandom *rand.Rand) (mutn Mutation, ok bool) {
+	mType := CalcMutationType(uniformRandom)
+	var fitnessEffect float32
+	switch mType {
+	case DELETERIOUS_DOMINANT, DELETERIOUS_RECESSIVE:
+		mType, fitnessEffect = calcDelMutationAttrs(mType, uniformRandom)
+	case FAVORABLE_DOMINANT, FAVORABLE_RECESSIVE:
+		mType, fitnessEffect = calcFavMutationAttrs(mType, uniformRandom)
+	case NEUTRAL:
+	default:
+		return Mutation{}, false
+	}
+	if math.Abs(float64(fitnessEffect)) > maxEffect { return Mutation{}, false }
+	return Mutation{Id: mutId, Type: mType, FitnessEffect: fitnessEffect}, true
+}
+
+
+// AppendStandingVariant adds a copy of a standing variant (shared by all of the genomes that carry it) to LB lbIndex of this chromosome.
+func (c *Chromosome) AppendStandingVariant(lbIndex int, mutn Mutation) {
+	lb := &c.LinkageBlocks[lbIndex]
+	switch mutn.Type {
+	case DELETERIOUS_DOMINANT, DELETERIOUS_RECESSIVE:
+		if config.Cfg.Computation.Tracking_threshold == 0.0 || mutn.FitnessEffect < -config.Cfg.Computation.Tracking_threshold { lb.appendMutn(mutn) }
+		lb.numDeleterious++
+	case NEUTRAL:
+		if config.Cfg.Computation.Track_neutrals { lb.appendMutn(mutn) }
+		lb.numNeutrals++
+	case FAVORABLE_DOMINANT, FAVORABLE_RECESSIVE:
+		if config.Cfg.Computation.Tracking_threshold == 0.0 || mutn.FitnessEffect > config.Cfg.Computation.Tracking_threshold { lb.appendMutn(mutn) }
+		lb.numFavorable++
+	}
+	lb.fitnessEffect += mutn.FitnessEffect
+	c.FitnessEffect += mutn.FitnessEffect
+}
@@ -219,6 +219,10 @@ func main() {
 		parentSpecies = nil 	// give GC a chance to reclaim the previous generation
 		if config.Cfg.Computation.Force_gc { utils.CollectGarbage() }
 		childrenSpecies.Select(uniformRandom)
+		childrenSpecies.ApplyDemographicEvents(gen, uniformRandom)
+		childrenSpecies.RecolonizeExtinctTribes(gen, uniformRandom)
+		childrenSpecies.Disperse(uniformRandom)
+		childrenSpecies.ApplyCatastrophes(gen, uniformRandom)
 
 		// Check if we should stop the run
 		lastGen := false
@@ -236,7 +240,7 @@ func main() {
 		totalInterimTime := utils.Measure.GetInterimTime("Total")
 		genTime := utils.Measure.Stop("Generations")
 		childrenSpecies.ReportEachGen(gen, lastGen, totalInterimTime, genTime)
-		childrenSpecies.MarkDonePops()		// effectively stops the tribes that have gone extinct or reached pop max
+		childrenSpecies.MarkDonePops(gen)		// effectively stops the tribes that have gone extinct or reached pop max
 		if lastGen { break }
 		parentSpecies = childrenSpecies        // for the next iteration
 	}
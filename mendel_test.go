@@ -106,6 +106,161 @@ func TestMendelCase16(t *testing.T) {
 	mendelCaseBin(t, 16, 16, "00000050.json", false, "", "")
 }
 
+// Same as TestMendelCase3 except with initial inversions and new inversions arising
+func TestMendelCase17(t *testing.T) {
+	mendelCase(t, 17, 17)
+	compareFiles(t, OUT_FILE_BASE+"17/"+config.INVERSIONS_FILENAME, EXP_FILE_BASE+"17/"+config.INVERSIONS_FILENAME)
+}
+
+// Same as TestMendelCase1 except with zygosity_fitness=true and mostly recessive mutations
+func TestMendelCase18(t *testing.T) {
+	mendelCase(t, 18, 18)
+}
+
+// Same as TestMendelCase18 except with weibull fitness effects and the hs-relationship dominance model
+func TestMendelCase19(t *testing.T) {
+	mendelCase(t, 19, 19)
+	compareFiles(t, OUT_FILE_BASE+"19/"+config.ALLELE_BINS_DIRECTORY+"00000020.json", EXP_FILE_BASE+"19/"+config.ALLELE_BINS_DIRECTORY+"00000020.json")
+	compareFiles(t, OUT_FILE_BASE+"19/"+config.DISTRIBUTION_DEL_DIRECTORY+"00000020.json", EXP_FILE_BASE+"19/"+config.DISTRIBUTION_DEL_DIRECTORY+"00000020.json")
+}
+
+// Same as TestMendelCase3 except with lethal and sterility mutations, most of them recessive
+func TestMendelCase20(t *testing.T) {
+	mendelCase(t, 20, 20)
+	compareFiles(t, OUT_FILE_BASE+"20/"+config.DEATHS_FILENAME, EXP_FILE_BASE+"20/"+config.DEATHS_FILENAME)
+}
+
+// Same as TestMendelCase3 except with trait mutations under stabilizing selection toward a linearly shifting optimum
+func TestMendelCase21(t *testing.T) {
+	mendelCase(t, 21, 21)
+	compareFiles(t, OUT_FILE_BASE+"21/"+config.ENVIRONMENT_FILENAME, EXP_FILE_BASE+"21/"+config.ENVIRONMENT_FILENAME)
+}
+
+// Same as TestMendelCase21 except with 3 correlated traits affected by pleiotropic trait mutations
+func TestMendelCase22(t *testing.T) {
+	mendelCase(t, 22, 22)
+	compareFiles(t, OUT_FILE_BASE+"22/"+config.ENVIRONMENT_FILENAME, EXP_FILE_BASE+"22/"+config.ENVIRONMENT_FILENAME)
+}
+
+// Same as TestMendelCase12 except with negative frequency dependent selection (balancing selection) on the initial alleles
+func TestMendelCase23(t *testing.T) {
+	mendelCaseBin(t, 23, 23, "00000100.json", true, "", "")
+}
+
+// Same as TestMendelCase3 except with a maternally inherited organelle genome
+func TestMendelCase24(t *testing.T) {
+	mendelCase(t, 24, 24)
+	compareFiles(t, OUT_FILE_BASE+"24/"+config.ORGANELLE_FILENAME, EXP_FILE_BASE+"24/"+config.ORGANELLE_FILENAME)
+}
+
+// Same as TestMendelCase3 except haploid, with a lower mutation rate, some fusion of the parents, and allele bins
+func TestMendelCase25(t *testing.T) {
+	mendelCaseBin(t, 25, 25, "00000020.json", false, "", "")
+}
+
+// Same as TestMendelCase3 except tetraploid, with all mutations tracked so dosage-based dominance applies to them
+func TestMendelCase26(t *testing.T) {
+	mendelCase(t, 26, 26)
+}
+
+// Same as TestMendelCase3 except with a male-biased mutation rate of 4:1 between the chromosomes from dad and from mom, with all mutations tracked so they can be counted by origin
+func TestMendelCase27(t *testing.T) {
+	mendelCase(t, 27, 27)
+	compareFiles(t, OUT_FILE_BASE+"27/"+config.MUTATION_ORIGIN_FILENAME, EXP_FILE_BASE+"27/"+config.MUTATION_ORIGIN_FILENAME)
+}
+
+// Same as TestMendelCase3 except with 1 maternally and 1 paternally expressed imprinted region
+func TestMendelCase28(t *testing.T) {
+	mendelCase(t, 28, 28)
+	compareFiles(t, OUT_FILE_BASE+"28/"+config.IMPRINTING_FILENAME, EXP_FILE_BASE+"28/"+config.IMPRINTING_FILENAME)
+}
+
+// Same as TestMendelCase3 except with mutators that change the mutation rate of their carriers
+func TestMendelCase29(t *testing.T) {
+	mendelCase(t, 29, 29)
+	compareFiles(t, OUT_FILE_BASE+"29/"+config.MUTATOR_FILENAME, EXP_FILE_BASE+"29/"+config.MUTATOR_FILENAME)
+}
+
+// Same as TestMendelCase3 except with overdispersed (negative binomial) mutation counts and germline mutation rate heterogeneity
+func TestMendelCase30(t *testing.T) {
+	mendelCase(t, 30, 30)
+	compareFiles(t, OUT_FILE_BASE+"30/"+config.NEW_MUTATION_COUNTS_FILENAME, EXP_FILE_BASE+"30/"+config.NEW_MUTATION_COUNTS_FILENAME)
+}
+
+// Same as TestMendelCase3 except half of each offspring's fitness before selection noise comes from its mother
+func TestMendelCase31(t *testing.T) {
+	mendelCase(t, 31, 31)
+}
+
+// Same as TestMendelCase3 except with the pedigree of the last 2 generations and the inbreeding coefficients output
+func TestMendelCase32(t *testing.T) {
+	mendelCase(t, 32, 32)
+	compareFiles(t, OUT_FILE_BASE+"32/"+config.PEDIGREE_FILENAME, EXP_FILE_BASE+"32/"+config.PEDIGREE_FILENAME)
+	compareFiles(t, OUT_FILE_BASE+"32/"+config.INBREEDING_FILENAME, EXP_FILE_BASE+"32/"+config.INBREEDING_FILENAME)
+}
+
+// Same as TestMendelCase3 except with the tree sequence recorded, simplified every 3 generations, and output (with a small pop, genome, and mutn_rate)
+func TestMendelCase33(t *testing.T) {
+	mendelCase(t, 33, 33)
+	for _, f := range []string{"nodes.txt", "edges.txt", "sites.txt", "mutations.txt"} {
+		compareFiles(t, OUT_FILE_BASE+"33/"+config.TREE_SEQUENCE_DIRECTORY+f, EXP_FILE_BASE+"33/"+config.TREE_SEQUENCE_DIRECTORY+f)
+	}
+}
+
+// Same as TestMendelCase3 except the genesis population starts with standing variation from the coalescent (with mutn_rate=10 and fraction_neutral=0.9)
+func TestMendelCase34(t *testing.T) {
+	mendelCase(t, 34, 34)
+}
+
+// Same as TestMendelCase3 except the pop size follows the linearly interpolated trajectory in testcase35-trajectory.csv (with reproductive_rate=2.0)
+func TestMendelCase35(t *testing.T) {
+	mendelCase(t, 35, 35)
+}
+
+// Same as TestMendelCase3 except tribe 1 is split, resized, split again, and merged with demographic_events
+func TestMendelCase36(t *testing.T) {
+	mendelCaseTribe(t, 36, 36)
+}
+
+// Same as TestMendelCase3 except with 3 tribes, tribe 2 goes extinct when testcase37-trajectory.csv crashes it in gen 5, and it is recolonized 3 generations later
+func TestMendelCase37(t *testing.T) {
+	mendelCaseTribe(t, 37, 37)
+}
+
+// Same as TestMendelCase3 except with 6 tribes on a 3x2 wrapped lattice with exponential dispersal, a pop_size gradient, and mendel.fst output
+func TestMendelCase38(t *testing.T) {
+	mendelCaseTribe(t, 38, 38)
+	compareFiles(t, OUT_FILE_BASE+"38/"+config.FST_FILENAME, EXP_FILE_BASE+"38/"+config.FST_FILENAME)
+	for _, tribeDir := range getTribeDirs(t, OUT_FILE_BASE+"38") {
+		compareFiles(t, OUT_FILE_BASE+"38/"+tribeDir+"/"+config.FST_FILENAME, EXP_FILE_BASE+"38/"+tribeDir+"/"+config.FST_FILENAME)
+	}
+}
+
+// Same as TestMendelCase3 except with 3 tribes on a 1d lattice with nearest-neighbor dispersal and locally adapted mutations
+func TestMendelCase39(t *testing.T) {
+	mendelCaseTribe(t, 39, 39)
+	compareFiles(t, OUT_FILE_BASE+"39/"+config.LOCAL_ADAPTATION_FILENAME, EXP_FILE_BASE+"39/"+config.LOCAL_ADAPTATION_FILENAME)
+	for _, tribeDir := range getTribeDirs(t, OUT_FILE_BASE+"39") {
+		compareFiles(t, OUT_FILE_BASE+"39/"+tribeDir+"/"+config.LOCAL_ADAPTATION_FILENAME, EXP_FILE_BASE+"39/"+tribeDir+"/"+config.LOCAL_ADAPTATION_FILENAME)
+	}
+}
+
+// Same as TestMendelCase3 except with 2 tribes and catastrophes that either cull the tribe or reduce its number of offspring
+func TestMendelCase40(t *testing.T) {
+	mendelCaseTribe(t, 40, 40)
+	compareFiles(t, OUT_FILE_BASE+"40/"+config.CATASTROPHE_FILENAME, EXP_FILE_BASE+"40/"+config.CATASTROPHE_FILENAME)
+	for _, tribeDir := range getTribeDirs(t, OUT_FILE_BASE+"40") {
+		compareFiles(t, OUT_FILE_BASE+"40/"+tribeDir+"/"+config.CATASTROPHE_FILENAME, EXP_FILE_BASE+"40/"+tribeDir+"/"+config.CATASTROPHE_FILENAME)
+	}
+}
+
+// Same as TestMendelCase3 except with a mixture of gamma and lognormal fitness effects, all mutations tracked, and allele distribution output
+func TestMendelCase41(t *testing.T) {
+	mendelCase(t, 41, 41)
+	compareFiles(t, OUT_FILE_BASE+"41/"+config.DISTRIBUTION_DEL_DIRECTORY+"00000020.json", EXP_FILE_BASE+"41/"+config.DISTRIBUTION_DEL_DIRECTORY+"00000020.json")
+	compareFiles(t, OUT_FILE_BASE+"41/"+config.DISTRIBUTION_FAV_DIRECTORY+"00000020.json", EXP_FILE_BASE+"41/"+config.DISTRIBUTION_FAV_DIRECTORY+"00000020.json")
+}
+
 // mendelCase runs a typical test case with an input file number and expected output file number.
 func mendelCase(t *testing.T, num, expNum int) {
 	numStr := strconv.Itoa(num)
@@ -190,6 +345,16 @@ func mendelCaseTribeBin(t *testing.T, num, expNum int, binFile string, andDistBi
 	}
 }
 
+// mendelCaseTribe runs a test case with multiple tribes and compares the summary files and the files of each tribe
+func mendelCaseTribe(t *testing.T, num, expNum int) {
+	mendelCase(t, num, expNum) // compare the summary files in the top dir
+	numStr := strconv.Itoa(num)
+	expNumStr := strconv.Itoa(expNum)
+	for _, tribeDir := range getTribeDirs(t, OUT_FILE_BASE+numStr) {
+		comparePlainFiles(t, numStr, expNumStr, OUT_FILE_BASE+numStr+"/"+tribeDir, EXP_FILE_BASE+numStr+"/"+tribeDir)
+	}
+}
+
 // Run a command with args, and return stdout, stderr
 func runCmd(t *testing.T, commandString string, args ...string) ([]byte, []byte, error) {
 	// For debug, build the full cmd string
@@ -0,0 +1,80 @@
+package pop
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/utils"
+)
+
+type CatastropheModelType string
+
+const (
+	CULL_CATASTROPHE    CatastropheModelType = "cull"
+	PENALTY_CATASTROPHE CatastropheModelType = "penalty"
+	MIXED_CATASTROPHE   CatastropheModelType = "mixed"
+)
+
+// Algorithms for what a catastrophe does to the tribe it strikes
+type CatastropheType func(p *Population, genNum uint32, uniformRandom *rand.Rand)
+
+// CullCatastrophe kills a random fraction (between 0 and catastrophe_max_cull) of the individuals of the tribe, regardless of their fitness
+func CullCatastrophe(p *Population, genNum uint32, uniformRandom *rand.Rand) {
+	fraction := (1.0 - uniformRandom.Float64()) * config.Cfg.Population.Catastrophe_max_cull		// in (0, max]
+	sizeBefore := p.GetCurrentSize()
+	numKilled := utils.MinUint32(uint32(utils.RoundInt(fraction * float64(sizeBefore))), sizeBefore)
+	p.IndivRefs, _ = p.randomSubset(int(sizeBefore - numKilled), uniformRandom)
+	p.clearCachedStats()
+	writeCatastrophe(genNum, p.TribeNum, CULL_CATASTROPHE, fraction, sizeBefore, p.GetCurrentSize())
+}
+
+// PenaltyCatastrophe reduces the number of offspring of the tribe in the next catastrophe_penalty_gens matings (starting with the one right after
+// the catastrophe) by a random fraction between 0 and catastrophe_max_penalty. So the tribe can shrink below its target size, or go extinct.
+func PenaltyCatastrophe(p *Population, genNum uint32, uniformRandom *rand.Rand) {
+	p.CatastrophePenalty = (1.0 - uniformRandom.Float64()) * config.Cfg.Population.Catastrophe_max_penalty
+	p.CatastrophePenaltyEndGen = genNum + config.Cfg.Population.Catastrophe_penalty_gens - 1
+	writeCatastrophe(genNum, p.TribeNum, PENALTY_CATASTROPHE, p.CatastrophePenalty, p.GetCurrentSize(), p.GetCurrentSize())
+}
+
+// MixedCatastrophe is equally likely to be a cull or a penalty
+func MixedCatastrophe(p *Population, genNum uint32, uniformRandom *rand.Rand) {
+	if uniformRandom.Float64() < 0.5 {
+		CullCatastrophe(p, genNum, uniformRandom)
+	} else {
+		PenaltyCatastrophe(p, genNum, uniformRandom)
+	}
+}
+
+
+// ApplyCatastrophes strikes each active tribe with a catastrophe with probability catastrophe_rate. It is called right after selection,
+// so a cull is reported in this generation, and a penalty applies to the children of the next generations.
+func (s *Species) ApplyCatastrophes(genNum uint32, uniformRandom *rand.Rand) {
+	if Mdl.Catastrophe == nil { return }
+	for _, p := range s.Populations {
+		if p.Done { continue }
+		if uniformRandom.Float64() < config.Cfg.Population.Catastrophe_rate { Mdl.Catastrophe(p, genNum, uniformRandom) }
+	}
+}
+
+// inheritCatastrophePenalty passes the penalty of a catastrophe down from the prev pop, if it still applies in this generation
+func (p *Population) inheritCatastrophePenalty(prevPop *Population, genNum uint32) {
+	if genNum > prevPop.CatastrophePenaltyEndGen { return }
+	p.CatastrophePenalty, p.CatastrophePenaltyEndGen = prevPop.CatastrophePenalty, prevPop.CatastrophePenaltyEndGen
+}
+
+// writeCatastropheHeader writes the header of the catastrophe file
+func writeCatastropheHeader(catWriter *os.File) {
+	fmt.Fprintln(catWriter, "# Generation  Tribe  Type  Magnitude  Size-before  Size-after")
+}
+
+// writeCatastrophe writes 1 catastrophe to the catastrophe file of the tribe, and to the catastrophe file of the whole species
+func writeCatastrophe(genNum, tribeNum uint32, cType CatastropheModelType, magnitude float64, sizeBefore, sizeAfter uint32) {
+	config.Verbose(2, "Gen %d: tribe %d suffered a %v catastrophe of magnitude %v", genNum, tribeNum, cType, magnitude)
+	// If you change this line, you must also change the header in writeCatastropheHeader()
+	line := fmt.Sprintf("%d  %d  %s  %v  %d  %d\n", genNum, tribeNum, cType, magnitude, sizeBefore, sizeAfter)
+	if catWriter := config.FMgr.GetFile(config.CATASTROPHE_FILENAME, tribeNum); catWriter != nil { fmt.Fprint(catWriter, line) }
+	if !config.MultipleTribes() { return }		// the species file is the same file as the tribe's
+	if catWriter0 := config.FMgr.GetFile(config.CATASTROPHE_FILENAME, 0); catWriter0 != nil { fmt.Fprint(catWriter0, line) }
+}
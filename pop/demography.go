@@ -0,0 +1,209 @@
+package pop
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/utils"
+)
+
+type DemographicEventType string
+
+const (
+	SPLIT_EVENT  DemographicEventType = "split"
+	MERGE_EVENT  DemographicEventType = "merge"
+	RESIZE_EVENT DemographicEventType = "resize"
+)
+
+// DemographicEvent is 1 element of the demographic_events config value
+type DemographicEvent struct {
+	Gen uint32		// the event is applied right after selection in this generation
+	Type DemographicEventType
+	Tribe uint32		// the tribe that is split or resized, or that the other tribe is merged into
+	OtherTribe uint32		// for split: the number of the new tribe. For merge: the tribe that is merged into Tribe.
+	Fraction float64		// for split: the fraction of Tribe that is moved to the new tribe
+	Size uint32		// for resize: the new target size of Tribe
+}
+
+
+// ParseDemographicEvents parses the config value that is comma-separated tuples gen:split:tribe:fraction, gen:merge:into-tribe:from-tribe,
+// or gen:resize:tribe:size, in increasing order of generation. The tribes each event refers to are checked against the tribes that will
+// exist at that point, and each split is assigned the number of the tribe it creates, so tribe numbers (and their output dirs) are never reused.
+func ParseDemographicEvents(eventsStr string, numTribes uint32) (events []DemographicEvent) {
+	errorStr := "Error: demographic_events must be like: gen:split:tribe:fraction,gen:merge:into-tribe:from-tribe,gen:resize:tribe:size,..."
+	tribes := make(map[uint32]bool)		// the tribes that exist after the events parsed so far
+	for t := uint32(1); t <= numTribes; t++ { tribes[t] = true }
+	nextTribeNum := numTribes + 1
+	for i, tuple := range strings.Split(eventsStr, ",") {
+		parts := strings.Split(strings.TrimSpace(tuple), ":")
+		if len(parts) != 4 { log.Fatal(errorStr) }
+		for j := range parts { parts[j] = strings.TrimSpace(parts[j]) }
+		var e DemographicEvent
+		gen, err := strconv.ParseUint(parts[0], 10, 32)
+		if err != nil { log.Fatalf("Error parsing the generation in element %d of demographic_events: %v", i+1, err) }
+		e.Gen = uint32(gen)
+		if e.Gen == 0 { log.Fatalf("Error: the generation in element %d of demographic_events must be > 0", i+1) }
+		if len(events) > 0 && e.Gen < events[len(events)-1].Gen { log.Fatalf("Error: the elements of demographic_events must be in increasing order of generation (element %d)", i+1) }
+		e.Type = DemographicEventType(strings.ToLower(parts[1]))
+		e.Tribe = parseEventTribe(parts[2], i+1, tribes)
+
+		switch e.Type {
+		case SPLIT_EVENT:
+			e.Fraction, err = strconv.ParseFloat(parts[3], 64)
+			if err != nil { log.Fatalf("Error parsing the fraction in element %d of demographic_events: %v", i+1, err) }
+			if e.Fraction <= 0.0 || e.Fraction >= 1.0 { log.Fatalf("Error: the fraction in element %d of demographic_events must be > 0.0 and < 1.0", i+1) }
+			e.OtherTribe = nextTribeNum
+			tribes[nextTribeNum] = true
+			nextTribeNum++
+		case MERGE_EVENT:
+			e.OtherTribe = parseEventTribe(parts[3], i+1, tribes)
+			if e.OtherTribe == e.Tribe { log.Fatalf("Error: element %d of demographic_events merges tribe %d into itself", i+1, e.Tribe) }
+			delete(tribes, e.OtherTribe)
+		case RESIZE_EVENT:
+			size, err := strconv.ParseUint(parts[3], 10, 32)
+			if err != nil { log.Fatalf("Error parsing the size in element %d of demographic_events: %v", i+1, err) }
+			if size == 0 { log.Fatalf("Error: the size in element %d of demographic_events must be > 0", i+1) }
+			e.Size = uint32(size)
+		default:
+			log.Fatalf("Error: unrecognized event type %v in element %d of demographic_events. It must be %v, %v, or %v.", parts[1], i+1, SPLIT_EVENT, MERGE_EVENT, RESIZE_EVENT)
+		}
+		events = append(events, e)
+	}
+	return
+}
+
+// parseEventTribe parses a tribe number in a demographic event and checks that the tribe will exist when the event is applied
+func parseEventTribe(tribeStr string, element int, tribes map[uint32]bool) uint32 {
+	tribeNum, err := strconv.ParseUint(tribeStr, 10, 32)
+	if err != nil { log.Fatalf("Error parsing the tribe in element %d of demographic_events: %v", element, err) }
+	if !tribes[uint32(tribeNum)] { log.Fatalf("Error: tribe %d in element %d of demographic_events will not exist at that point", tribeNum, element) }
+	return uint32(tribeNum)
+}
+
+
+// String returns the event in the same form it has in demographic_events
+func (e DemographicEvent) String() string {
+	switch e.Type {
+	case SPLIT_EVENT:
+		return fmt.Sprintf("%d:%s:%d:%v", e.Gen, e.Type, e.Tribe, e.Fraction)
+	case MERGE_EVENT:
+		return fmt.Sprintf("%d:%s:%d:%d", e.Gen, e.Type, e.Tribe, e.OtherTribe)
+	}
+	return fmt.Sprintf("%d:%s:%d:%d", e.Gen, e.Type, e.Tribe, e.Size)
+}
+
+
+// ApplyDemographicEvents applies the demographic events scheduled for this generation. It is called right after selection, so the
+// tribes it creates or changes are reported and mated like the others. Events on a tribe that has gone extinct or reached its pop max are skipped.
+func (s *Species) ApplyDemographicEvents(genNum uint32, uniformRandom *rand.Rand) {
+	for _, e := range Mdl.DemographicEvents {
+		if e.Gen != genNum { continue }
+		p := s.getActivePopulation(e.Tribe)
+		if p == nil {
+			log.Printf("Skipping demographic event %v because tribe %d is no longer active", e, e.Tribe)
+			continue
+		}
+
+		switch e.Type {
+		case SPLIT_EVENT:
+			newP := p.split(e.OtherTribe, e.Fraction, uniformRandom)
+			config.FMgr.OpenTribeFiles(newP.TribeNum)
+			newP.ReportInitial()
+			s.Populations = append(s.Populations, newP)
+			log.Printf("Gen %d: split %d individuals from tribe %d into new tribe %d", genNum, newP.GetCurrentSize(), p.TribeNum, newP.TribeNum)
+		case MERGE_EVENT:
+			other := s.getActivePopulation(e.OtherTribe)
+			if other == nil {
+				log.Printf("Skipping demographic event %v because tribe %d is no longer active", e, e.OtherTribe)
+				continue
+			}
+			p.IndivRefs = append(p.IndivRefs, other.IndivRefs...)
+			p.TargetSize += other.TargetSize
+			p.clearCachedStats()
+			s.removePopulation(other)
+			log.Printf("Gen %d: merged the %d individuals of tribe %d into tribe %d", genNum, other.GetCurrentSize(), other.TribeNum, p.TribeNum)
+		case RESIZE_EVENT:
+			p.TargetSize = e.Size
+			if p.GetCurrentSize() > e.Size {
+				p.IndivRefs, _ = p.randomSubset(int(e.Size), uniformRandom)
+				p.clearCachedStats()
+			}
+			log.Printf("Gen %d: resized tribe %d to a target size of %d", genNum, p.TribeNum, e.Size)
+		}
+	}
+}
+
+// getActivePopulation returns the tribe with this number, or nil if it does not exist or is done
+func (s *Species) getActivePopulation(tribeNum uint32) *Population {
+	for _, p := range s.Populations {
+		if p.TribeNum == tribeNum && !p.Done { return p }
+	}
+	return nil
+}
+
+// removePopulation removes the tribe from the species
+func (s *Species) removePopulation(p *Population) {
+	for i := range s.Populations {
+		if s.Populations[i] == p {
+			s.Populations = append(s.Populations[:i], s.Populations[i+1:]...)
+			return
+		}
+	}
+}
+
+
+// split moves a random fraction of the individuals of this population into a new population and returns it. The target size is divided in the same proportion.
+func (p *Population) split(newTribeNum uint32, fraction float64, uniformRandom *rand.Rand) *Population {
+	numMoved := utils.RoundInt(fraction * float64(p.GetCurrentSize()))
+	moved, stayed := p.randomSubset(numMoved, uniformRandom)
+	newP := &Population{
+		TribeNum: newTribeNum,
+		IndivRefs: moved,
+		TargetSize: utils.MaxUint32(uint32(utils.RoundInt(fraction * float64(p.TargetSize))), 1),
+		Num_offspring: p.Num_offspring,
+		LBsPerChromosome: p.LBsPerChromosome,
+		Optimum: p.Optimum,
+		ParentAlleleFreqs: p.ParentAlleleFreqs,
+		ActualAvgOffspring: p.ActualAvgOffspring,
+		PreSelGenoFitnessMean: p.PreSelGenoFitnessMean,
+		PreSelGenoFitnessVariance: p.PreSelGenoFitnessVariance,
+		PreSelGenoFitnessStDev: p.PreSelGenoFitnessStDev,
+		EnvironNoise: p.EnvironNoise,
+		CatastrophePenalty: p.CatastrophePenalty,
+		CatastrophePenaltyEndGen: p.CatastrophePenaltyEndGen,
+	}
+	if p.BottleNecks != nil {
+		bottlenecks := *p.BottleNecks		// the new tribe needs its own position in the bottleneck list
+		newP.BottleNecks = &bottlenecks
+	}
+	p.IndivRefs = stayed
+	p.TargetSize = utils.MaxUint32(p.TargetSize - utils.MinUint32(newP.TargetSize, p.TargetSize), 1)
+	p.clearCachedStats()
+	return newP
+}
+
+// randomSubset randomly chooses num of the individuals of this population. It returns the chosen individuals and the rest, each in their original order.
+func (p *Population) randomSubset(num int, uniformRandom *rand.Rand) (chosen, rest []IndivRef) {
+	isChosen := make([]bool, len(p.IndivRefs))
+	for _, i := range uniformRandom.Perm(len(p.IndivRefs))[:num] { isChosen[i] = true }
+	chosen = make([]IndivRef, 0, num)
+	rest = make([]IndivRef, 0, len(p.IndivRefs)-num)
+	for i, indRef := range p.IndivRefs {
+		if isChosen[i] {
+			chosen = append(chosen, indRef)
+		} else {
+			rest = append(rest, indRef)
+		}
+	}
+	return
+}
+
+// clearCachedStats makes GetFitnessStats() and GetMutationStats() recalculate their values after individuals are moved in or out of this population
+func (p *Population) clearCachedStats() {
+	p.MeanFitness = 0.0
+	p.MeanNumDeleterious = 0.0
+}
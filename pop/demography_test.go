@@ -0,0 +1,75 @@
+package pop
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Checks that each split gets the next unused tribe number, even after a merge removes a tribe, and that the other fields are parsed
+func TestParseDemographicEvents(t *testing.T) {
+	events := ParseDemographicEvents("10:split:1:0.5, 20:split:2:0.3, 30:merge:1:3, 30:resize:4:25, 40:Split:1:0.2, 50:split:5:0.5", 2)
+	want := []DemographicEvent{
+		{Gen: 10, Type: SPLIT_EVENT, Tribe: 1, OtherTribe: 3, Fraction: 0.5},
+		{Gen: 20, Type: SPLIT_EVENT, Tribe: 2, OtherTribe: 4, Fraction: 0.3},
+		{Gen: 30, Type: MERGE_EVENT, Tribe: 1, OtherTribe: 3},
+		{Gen: 30, Type: RESIZE_EVENT, Tribe: 4, Size: 25},
+		{Gen: 40, Type: SPLIT_EVENT, Tribe: 1, OtherTribe: 5, Fraction: 0.2},		// tribe 3 was merged away, but its number is not reused
+		{Gen: 50, Type: SPLIT_EVENT, Tribe: 5, OtherTribe: 6, Fraction: 0.5},		// a tribe created by a split can be split again
+	}
+	if len(events) != len(want) { t.Fatalf("expected %d events, got %d: %v", len(want), len(events), events) }
+	for i := range want {
+		if events[i] != want[i] { t.Errorf("event %d: expected %+v, got %+v", i+1, want[i], events[i]) }
+	}
+}
+
+// makeSplitPop returns a population of size individuals with the target size
+func makeSplitPop(size int, targetSize uint32) *Population {
+	p := &Population{TribeNum: 1, TargetSize: targetSize, Num_offspring: 2.0}
+	for i := 0; i < size; i++ { p.IndivRefs = append(p.IndivRefs, IndivRef{Indiv: &Individual{}}) }
+	return p
+}
+
+// Checks that randomSubset chooses exactly num distinct individuals, and the rest are the others, each in their original order
+func TestRandomSubset(t *testing.T) {
+	p := makeSplitPop(20, 20)
+	index := make(map[*Individual]int)
+	for i, indRef := range p.IndivRefs { index[indRef.Indiv] = i }
+	uniformRandom := rand.New(rand.NewSource(1))
+	for _, num := range []int{0, 1, 7, 20} {
+		chosen, rest := p.randomSubset(num, uniformRandom)
+		if len(chosen) != num || len(rest) != 20 - num { t.Errorf("num %d: expected %d chosen and %d rest, got %d and %d", num, num, 20 - num, len(chosen), len(rest)) }
+		seen := make(map[*Individual]bool)
+		for _, part := range [][]IndivRef{chosen, rest} {
+			for j, indRef := range part {
+				if seen[indRef.Indiv] { t.Errorf("num %d: an individual was returned twice", num) }
+				seen[indRef.Indiv] = true
+				if j > 0 && index[indRef.Indiv] < index[part[j-1].Indiv] { t.Errorf("num %d: individuals are not in their original order", num) }
+			}
+		}
+		if len(seen) != 20 { t.Errorf("num %d: expected all 20 individuals to be returned, got %d", num, len(seen)) }
+	}
+}
+
+// Checks the sizes and target sizes of both tribes after a split, including the rounding and the minimum target size of 1
+func TestSplitSizes(t *testing.T) {
+	tests := []struct {
+		size int
+		targetSize uint32
+		fraction float64
+		wantMoved, wantNewTarget, wantOldTarget uint32
+	}{
+		{10, 10, 0.3, 3, 3, 7},
+		{10, 40, 0.25, 3, 10, 30},		// the current size is rounded: 2.5 -> 3
+		{10, 100, 0.5, 5, 50, 50},
+		{4, 1, 0.5, 2, 1, 1},		// neither target size can go below 1
+	}
+	uniformRandom := rand.New(rand.NewSource(1))
+	for _, tc := range tests {
+		p := makeSplitPop(tc.size, tc.targetSize)
+		newP := p.split(2, tc.fraction, uniformRandom)
+		if newP.TribeNum != 2 { t.Errorf("expected the new tribe to be 2, got %d", newP.TribeNum) }
+		if newP.GetCurrentSize() != tc.wantMoved || p.GetCurrentSize() != uint32(tc.size) - tc.wantMoved { t.Errorf("%+v: expected sizes %d and %d, got %d and %d", tc, uint32(tc.size) - tc.wantMoved, tc.wantMoved, p.GetCurrentSize(), newP.GetCurrentSize()) }
+		if newP.TargetSize != tc.wantNewTarget || p.TargetSize != tc.wantOldTarget { t.Errorf("%+v: expected target sizes %d and %d, got %d and %d", tc, tc.wantOldTarget, tc.wantNewTarget, p.TargetSize, newP.TargetSize) }
+		if newP.Num_offspring != p.Num_offspring { t.Errorf("expected the new tribe to get num_offspring %v, got %v", p.Num_offspring, newP.Num_offspring) }
+	}
+}
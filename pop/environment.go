@@ -0,0 +1,155 @@
+package pop
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+)
+
+// CalcOptimumType functions return the optimum value of the quantitative trait in the specified generation
+type CalcOptimumType func(genNum uint32) float64
+
+// ConstantOptimum keeps the optimum at 0, i.e. at the trait value of the genesis population
+func ConstantOptimum(_ uint32) float64 { return 0.0 }
+
+// LinearOptimum moves the optimum optimum_shift each generation
+func LinearOptimum(genNum uint32) float64 { return config.Cfg.Selection.Optimum_shift * float64(genNum) }
+
+// StepOptimum moves the optimum optimum_shift every optimum_period generations
+func StepOptimum(genNum uint32) float64 {
+	return config.Cfg.Selection.Optimum_shift * float64(genNum / config.Cfg.Selection.Optimum_period)
+}
+
+// CyclicOptimum moves the optimum in a sine wave with amplitude optimum_shift and period optimum_period
+func CyclicOptimum(genNum uint32) float64 {
+	return config.Cfg.Selection.Optimum_shift * math.Sin(2.0 * math.Pi * float64(genNum) / float64(config.Cfg.Selection.Optimum_period))
+}
+
+
+// EnvironmentalFitness returns the factor an individual's fitness is multiplied by, given its trait values: gaussian stabilizing selection toward the optimum.
+// The optimum of the 1st trait is the one that moves, the optimum of any other traits is 0.
+func EnvironmentalFitness(traitValues []float64, optimum float64) float64 {
+	dist := traitValues[0] - optimum
+	distSquared := dist * dist
+	for _, z := range traitValues[1:] { distSquared += z * z }
+	width := config.Cfg.Selection.Selection_width
+	return math.Exp(-distSquared / (2.0 * width * width))
+}
+
+
+// CalcTraitValues sets the value of each quantitative trait of this individual, which is the sum of the effects of all of its trait mutations
+func (ind *Individual) CalcTraitValues() {
+	numTraits := int(config.Cfg.Mutations.Num_traits)
+	if cap(ind.TraitValues) < numTraits {
+		ind.TraitValues = make([]float64, numTraits)
+	} else {
+		ind.TraitValues = ind.TraitValues[:numTraits]
+		for t := range ind.TraitValues { ind.TraitValues[t] = 0.0 }
+	}
+	for c := range ind.ChromosomesFromDad { ind.ChromosomesFromDad[c].AddTraitValues(ind.TraitValues) }
+	for c := range ind.ChromosomesFromMom { ind.ChromosomesFromMom[c].AddTraitValues(ind.TraitValues) }
+}
+
+
+// environmentStats accumulates the trait values of the individuals of 1 or more populations
+type environmentStats struct {
+	count uint32
+	sum, sumSquares []float64		// indexed by trait
+	sumEnvFitness float64
+}
+
+func newEnvironmentStats() *environmentStats {
+	return &environmentStats{sum: make([]float64, config.Cfg.Mutations.Num_traits), sumSquares: make([]float64, config.Cfg.Mutations.Num_traits)}
+}
+
+// gatherEnvironmentStats adds the trait values of the individuals in this population to stats
+func (p *Population) gatherEnvironmentStats(stats *environmentStats) {
+	for _, indRef := range p.IndivRefs {
+		ind := indRef.Indiv
+		if len(ind.TraitValues) == 0 { ind.CalcTraitValues() }		// the genesis individuals were not created by AddMutations()
+		stats.count++
+		for t, z := range ind.TraitValues {
+			stats.sum[t] += z
+			stats.sumSquares[t] += z * z
+		}
+		stats.sumEnvFitness += EnvironmentalFitness(ind.TraitValues, p.Optimum)
+	}
+}
+
+// calcOptimum returns the optimum of this population's environment in this generation
+func (p *Population) calcOptimum(genNum uint32) float64 {
+	if Mdl.Lattice != nil { return Mdl.CalcOptimum(genNum) + Mdl.Lattice.OptimumOffset(p.TribeNum) }
+	return Mdl.CalcOptimum(genNum)
+}
+
+// meanOptimum returns the mean optimum of the tribes that are not done
+func meanOptimum(pops []*Population) float64 {
+	var sum float64
+	var num int
+	for _, p := range pops {
+		if p.Done { continue }
+		sum += p.Optimum
+		num++
+	}
+	if num == 0 { return 0.0 }
+	return sum / float64(num)
+}
+
+// isExtinct returns true if this population can no longer continue (the same conditions that IsDone() checks, except for reaching the max pop size)
+func (p *Population) isExtinct() bool {
+	if p.tooFewToMate() { return true }
+	aveFit, _, _, _, _ := p.GetFitnessStats()
+	return aveFit < config.Cfg.Computation.Extinction_threshold
+}
+
+// writeEnvironmentStats writes 1 line to the environment file
+func writeEnvironmentStats(envWriter *os.File, genNum uint32, optimum float64, stats *environmentStats, extinct bool) {
+	numTraits := len(stats.sum)
+	means := make([]float64, numTraits)
+	variances := make([]float64, numTraits)
+	var meanEnvFitness float64
+	if stats.count > 0 {
+		n := float64(stats.count)
+		for t := range means {
+			means[t] = stats.sum[t] / n
+			variances[t] = math.Max(0.0, stats.sumSquares[t] / n - means[t] * means[t])
+		}
+		meanEnvFitness = stats.sumEnvFitness / n
+	}
+	extinctNum := 0
+	if extinct { extinctNum = 1 }
+	// If you change this line, you must also change the header in writeEnvironmentHeader()
+	fmt.Fprintf(envWriter, "%d  %v  %v  %v  %v  %v  %d", genNum, optimum, means[0], variances[0], optimum - means[0], meanEnvFitness, extinctNum)
+	if numTraits > 1 {
+		// The distance of the mean trait values from the optimum in the multi-dimensional trait space
+		distSquared := (optimum - means[0]) * (optimum - means[0])
+		for t := 1; t < numTraits; t++ {
+			fmt.Fprintf(envWriter, "  %v  %v", means[t], variances[t])
+			distSquared += means[t] * means[t]
+		}
+		fmt.Fprintf(envWriter, "  %v", math.Sqrt(distSquared))
+	}
+	fmt.Fprintln(envWriter)
+}
+
+// writeEnvironmentHeader writes the header of the environment file. The trait columns before Extinct are for the 1st trait.
+func writeEnvironmentHeader(envWriter *os.File) {
+	fmt.Fprint(envWriter, "# Generation  Optimum  Trait-mean  Trait-variance  Lag  Mean-environmental-fitness  Extinct")
+	if config.Cfg.Mutations.Num_traits > 1 {
+		for t := uint32(2); t <= config.Cfg.Mutations.Num_traits; t++ { fmt.Fprintf(envWriter, "  Trait%d-mean  Trait%d-variance", t, t) }
+		fmt.Fprint(envWriter, "  Distance")
+	}
+	fmt.Fprintln(envWriter)
+}
+
+// ReportEnvironment writes the optimum and the trait stats of this population, and how far the mean trait lags behind the optimum.
+func (p *Population) ReportEnvironment(genNum uint32) {
+	if envWriter := config.FMgr.GetFile(config.ENVIRONMENT_FILENAME, p.TribeNum); envWriter != nil {
+		config.Verbose(5, "Writing to file %v", config.ENVIRONMENT_FILENAME)
+		stats := newEnvironmentStats()
+		p.gatherEnvironmentStats(stats)
+		writeEnvironmentStats(envWriter, genNum, p.Optimum, stats, p.isExtinct())
+	}
+}
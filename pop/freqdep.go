@@ -0,0 +1,68 @@
+package pop
+
+import (
+	"math"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/dna"
+)
+
+// CalcFrequencyDependentEffectType functions return the adjustment to the fitness effect of a frequency dependent mutation, given its fitness effect and its allele frequency in the parent generation
+type CalcFrequencyDependentEffectType func(fitnessEffect float32, freq float64) float64
+
+// NegativeFrequencyDependentEffect makes the mutation more beneficial (or less harmful) when it is rarer than frequency_dependent_target, and less beneficial when it is more common. This is balancing selection.
+func NegativeFrequencyDependentEffect(fitnessEffect float32, freq float64) float64 {
+	return config.Cfg.Selection.Frequency_dependent_strength * math.Abs(float64(fitnessEffect)) * (config.Cfg.Selection.Frequency_dependent_target - freq)
+}
+
+// PositiveFrequencyDependentEffect makes the mutation more beneficial (or less harmful) when it is more common than frequency_dependent_target, and less beneficial when it is rarer.
+func PositiveFrequencyDependentEffect(fitnessEffect float32, freq float64) float64 {
+	return -NegativeFrequencyDependentEffect(fitnessEffect, freq)
+}
+
+
+// IsFrequencyDependentType functions return true if mutations of this type are frequency dependent, according to frequency_dependent_mutations
+type IsFrequencyDependentType func(mType dna.MutationType) bool
+
+func IsInitialAllele(mType dna.MutationType) bool { return mType == dna.DEL_ALLELE || mType == dna.FAV_ALLELE }
+
+func IsDeleteriousMutation(mType dna.MutationType) bool { return mType == dna.DELETERIOUS_DOMINANT || mType == dna.DELETERIOUS_RECESSIVE }
+
+func IsFavorableMutation(mType dna.MutationType) bool { return mType == dna.FAVORABLE_DOMINANT || mType == dna.FAVORABLE_RECESSIVE }
+
+func IsAnyFitnessMutation(mType dna.MutationType) bool { return IsInitialAllele(mType) || IsDeleteriousMutation(mType) || IsFavorableMutation(mType) }
+
+
+// calcAlleleFreqs returns the allele frequency in this population of each of the tracked mutations and initial alleles whose type isIncluded, using the same allele counts as the allele-bins output.
+func (p *Population) calcAlleleFreqs(genNum uint32, isIncluded func(mType dna.MutationType) bool) (freqs map[uint64]float64) {
+	freqs = make(map[uint64]float64)
+	popSize := p.GetCurrentSize()
+	if popSize == 0 { return }
+	alleles := p.getAlleles(genNum, popSize, false)
+	// This is the same as in fillBuckets()
+	poolSize := float64(config.Cfg.Population.Ploidy * popSize)
+	if !config.Cfg.Computation.Count_duplicate_alleles { poolSize = float64(popSize)}	// in this case, each allele count is a measure of how many individuals it occurred in
+
+	add := func(mType dna.MutationType, counts map[uint64]dna.Allele) {
+		if !isIncluded(mType) { return }
+		for id, allele := range counts { freqs[id] = float64(allele.Count) / poolSize }
+	}
+	add(dna.DELETERIOUS_DOMINANT, alleles.DeleteriousDom)
+	add(dna.DELETERIOUS_RECESSIVE, alleles.DeleteriousRec)
+	add(dna.NEUTRAL, alleles.Neutral)
+	add(dna.FAVORABLE_DOMINANT, alleles.FavorableDom)
+	add(dna.FAVORABLE_RECESSIVE, alleles.FavorableRec)
+	add(dna.DEL_ALLELE, alleles.DelInitialAlleles)
+	add(dna.FAV_ALLELE, alleles.FavInitialAlleles)
+	return
+}
+
+
+// FrequencyDependentFitness returns the total adjustment to this individual's fitness from its frequency dependent mutations, given their allele
+// frequencies in the parent generation. A mutation that was not present in the parent generation has a frequency of 0.
+func (ind *Individual) FrequencyDependentFitness(parentAlleleFreqs map[uint64]float64) (fitness float64) {
+	effectFunc := func(m dna.Mutation) float64 { return Mdl.CalcFrequencyDependentEffect(m.FitnessEffect, parentAlleleFreqs[m.Id]) }
+	for c := range ind.ChromosomesFromDad { fitness += ind.ChromosomesFromDad[c].SumTrackedMutations(Mdl.IsFrequencyDependent, effectFunc) }
+	for c := range ind.ChromosomesFromMom { fitness += ind.ChromosomesFromMom[c].SumTrackedMutations(Mdl.IsFrequencyDependent, effectFunc) }
+	return
+}
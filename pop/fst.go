@@ -0,0 +1,76 @@
+package pop
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/dna"
+)
+
+// writeFstHeader writes the header of the fst file of the whole species
+func writeFstHeader(fstWriter *os.File) {
+	fmt.Fprintln(fstWriter, "# Generation  Tribe-1  Tribe-2  Distance  Fst")
+}
+
+// writeTribeFstHeader writes the header of the fst file of a tribe, which has the fst between it and each other tribe
+func writeTribeFstHeader(fstWriter *os.File) {
+	fmt.Fprintln(fstWriter, "# Generation  Other-tribe  Distance  Fst")
+}
+
+
+// isAnyTrackedMutation includes every type of tracked mutation and initial allele in the allele frequencies fst is calculated from
+func isAnyTrackedMutation(dna.MutationType) bool { return true }
+
+// calcFst returns Nei's Fst between 2 populations from their allele frequencies: the sum over all of the alleles of (Ht - Hs) divided by the sum of Ht,
+// where Hs is the mean expected heterozygosity within the 2 populations and Ht is the expected heterozygosity of the pooled population.
+func calcFst(freqs1, freqs2 map[uint64]float64) float64 {
+	// Sum in the order of the allele ids, so the result does not depend on the map iteration order
+	ids := make([]uint64, 0, len(freqs1) + len(freqs2))
+	for id := range freqs1 { ids = append(ids, id) }
+	for id := range freqs2 {
+		if _, ok := freqs1[id]; !ok { ids = append(ids, id) }
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var sumDiff, sumHt float64
+	for _, id := range ids {
+		p1, p2 := freqs1[id], freqs2[id]
+		pMean := (p1 + p2) / 2.0
+		hs := (2.0 * p1 * (1.0 - p1) + 2.0 * p2 * (1.0 - p2)) / 2.0
+		ht := 2.0 * pMean * (1.0 - pMean)
+		sumDiff += ht - hs
+		sumHt += ht
+	}
+	if sumHt <= 0.0 { return 0.0 }
+	return sumDiff / sumHt
+}
+
+
+// reportFst writes the fst and distance between each pair of active tribes to the species fst file, and to the fst file of each of the 2 tribes
+func (s *Species) reportFst(genNum uint32) {
+	var tribes []*Population
+	var freqs []map[uint64]float64
+	for _, p := range s.Populations {
+		if p.Done { continue }
+		tribes = append(tribes, p)
+		freqs = append(freqs, p.calcAlleleFreqs(genNum, isAnyTrackedMutation))
+	}
+
+	fstWriter := config.FMgr.GetFile(config.FST_FILENAME, 0)
+	for i := range tribes {
+		for j := i + 1; j < len(tribes); j++ {
+			distance := tribeDistance(tribes[i].TribeNum, tribes[j].TribeNum)
+			fst := calcFst(freqs[i], freqs[j])
+			// If you change these lines, you must also change the headers in writeFstHeader() and writeTribeFstHeader()
+			fmt.Fprintf(fstWriter, "%d  %d  %d  %v  %v\n", genNum, tribes[i].TribeNum, tribes[j].TribeNum, distance, fst)
+			if tribeWriter := config.FMgr.GetFile(config.FST_FILENAME, tribes[i].TribeNum); tribeWriter != nil {
+				fmt.Fprintf(tribeWriter, "%d  %d  %v  %v\n", genNum, tribes[j].TribeNum, distance, fst)
+			}
+			if tribeWriter := config.FMgr.GetFile(config.FST_FILENAME, tribes[j].TribeNum); tribeWriter != nil {
+				fmt.Fprintf(tribeWriter, "%d  %d  %v  %v\n", genNum, tribes[i].TribeNum, distance, fst)
+			}
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package pop
+
+import (
+	"math"
+	"testing"
+)
+
+// Checks Nei's Fst for populations that are identical, fixed for different alleles, and partly differentiated, including alleles missing from 1 population
+func TestCalcFst(t *testing.T) {
+	tests := []struct {
+		name string
+		freqs1, freqs2 map[uint64]float64
+		want float64
+	}{
+		{"identical", map[uint64]float64{1: 0.3, 2: 0.5}, map[uint64]float64{1: 0.3, 2: 0.5}, 0.0},
+		{"fixed differences", map[uint64]float64{1: 1.0}, map[uint64]float64{2: 1.0}, 1.0},
+		{"1 allele", map[uint64]float64{1: 0.2}, map[uint64]float64{1: 0.6}, 0.08 / 0.48},		// Ht = 2*0.4*0.6 = 0.48, Hs = (0.32+0.48)/2 = 0.4
+		{"allele missing from 1 population", map[uint64]float64{1: 0.5}, map[uint64]float64{}, 0.125 / 0.375},		// Ht = 2*0.25*0.75 = 0.375, Hs = 0.5/2 = 0.25
+		{"2 alleles", map[uint64]float64{1: 0.2, 2: 0.5}, map[uint64]float64{1: 0.6, 2: 0.5}, 0.08 / 0.98},		// allele 2 adds 0.5 to Ht and Hs
+		{"no variation", map[uint64]float64{}, map[uint64]float64{}, 0.0},
+	}
+	for _, tc := range tests {
+		if got := calcFst(tc.freqs1, tc.freqs2); math.Abs(got - tc.want) > 1e-12 { t.Errorf("%s: expected fst %v, got %v", tc.name, tc.want, got) }
+		if got := calcFst(tc.freqs2, tc.freqs1); math.Abs(got - tc.want) > 1e-12 { t.Errorf("%s: expected fst %v with the populations swapped, got %v", tc.name, tc.want, got) }
+	}
+}
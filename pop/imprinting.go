@@ -0,0 +1,134 @@
+package pop
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+)
+
+// ImprintedRegion is 1 element of the imprinted_regions config parameter
+type ImprintedRegion struct {
+	ChromoIndex int		// 0 based
+	Start uint32		// 0 based LB index within the chromosome
+	End uint32		// 0 based LB index within the chromosome, inclusive
+	MaternalExpressed bool		// true if the copy from mom is expressed and the copy from dad is silenced, false for the reverse
+}
+
+// ParseImprintedRegions parses the config value that is comma-separated 4-tuples chromosome:first-lb:last-lb:expressed (chromosome and LB numbers are 1 based).
+func ParseImprintedRegions(regionsStr string, lBsPerChromosome uint32) (regions []ImprintedRegion) {
+	errorStr := "Error: imprinted_regions must be like: chromosome:first-lb:last-lb:expressed, ... where expressed is maternal or paternal"
+	if strings.TrimSpace(regionsStr) == "" { log.Fatal(errorStr) }
+	for i, t := range strings.Split(regionsStr, ",") {
+		parts := strings.Split(strings.TrimSpace(t), ":")
+		if len(parts) != 4 { log.Fatal(errorStr) }
+		var nums [3]int64
+		for j := 0; j < 3; j++ {
+			var err error
+			nums[j], err = strconv.ParseInt(strings.TrimSpace(parts[j]), 10, 32)
+			if err != nil { log.Fatalf("Error parsing element %d of imprinted_regions: %v", i+1, err) }
+		}
+		var maternalExpressed bool
+		switch strings.ToLower(strings.TrimSpace(parts[3])) {
+		case "maternal":
+			maternalExpressed = true
+		case "paternal":
+			maternalExpressed = false
+		default:
+			log.Fatalf("Error: expressed in element %d of imprinted_regions must be maternal or paternal, not %v", i+1, parts[3])
+		}
+
+		if nums[0] < 1 || nums[0] > int64(config.Cfg.Population.Haploid_chromosome_number) { log.Fatalf("Error: chromosome in element %d of imprinted_regions must be between 1 and %d, not %d", i+1, config.Cfg.Population.Haploid_chromosome_number, nums[0]) }
+		if nums[1] < 1 || nums[2] < nums[1] || nums[2] > int64(lBsPerChromosome) { log.Fatalf("Error: first-lb and last-lb in element %d of imprinted_regions must satisfy 1 <= first-lb <= last-lb <= %d", i+1, lBsPerChromosome) }
+		newRegion := ImprintedRegion{ChromoIndex: int(nums[0] - 1), Start: uint32(nums[1] - 1), End: uint32(nums[2] - 1), MaternalExpressed: maternalExpressed}
+
+		// Regions on the same chromosome can not overlap, because then an LB could have both copies silenced
+		for _, r := range regions {
+			if r.ChromoIndex == newRegion.ChromoIndex && r.Start <= newRegion.End && newRegion.Start <= r.End { log.Fatalf("Error: element %d of imprinted_regions overlaps a previous element on the same chromosome", i+1) }
+		}
+		regions = append(regions, newRegion)
+	}
+	return
+}
+
+
+// ImprintingLoad returns the fitness effect and number of deleterious mutations of the silenced and of the expressed copies of this individual's imprinted regions.
+// The expressed copy is functionally hemizygous, so its fitness effect is the full effect of its mutations, and hemizygousCorrection is the amount that
+// has to be added to the heterozygous effects that CalcIndivFitness() included to get that.
+func (ind *Individual) ImprintingLoad() (silencedFitness, expressedFitness, hemizygousCorrection float64, silencedDel, expressedDel uint32) {
+	for _, r := range Mdl.ImprintedRegions {
+		expressed, silenced := &ind.ChromosomesFromDad[r.ChromoIndex], &ind.ChromosomesFromMom[r.ChromoIndex]
+		if r.MaternalExpressed { expressed, silenced = silenced, expressed }
+		fitness, numDel := silenced.RegionMutationLoad(r.Start, r.End)
+		silencedFitness += float64(fitness)
+		silencedDel += numDel
+		fitness, numDel = expressed.RegionMutationLoad(r.Start, r.End)
+		for i := r.Start; i <= r.End && i < expressed.GetNumLinkages(); i++ {
+			hemizygousCorrection += expressed.LinkageBlocks[i].HemizygousCorrection()
+		}
+		expressedFitness += float64(fitness)
+		expressedDel += numDel
+	}
+	expressedFitness += hemizygousCorrection
+	return
+}
+
+
+// ImprintingFitnessAdjustment returns the amount the fitness from CalcIndivFitness() must be adjusted by for the imprinted regions. CalcIndivFitness()
+// included the mutations on the silenced copies, and those on the expressed copies only with their heterozygous effect.
+func (ind *Individual) ImprintingFitnessAdjustment() float64 {
+	silencedFitness, _, hemizygousCorrection, _, _ := ind.ImprintingLoad()
+	return hemizygousCorrection - silencedFitness
+}
+
+
+// imprintingStats accumulates the mutation load in the imprinted regions for the individuals of 1 or more populations
+type imprintingStats struct {
+	count uint32
+	silencedFitness, expressedFitness float64
+	silencedDel, expressedDel uint64
+}
+
+// gatherImprintingStats adds the individuals in this population to stats
+func (p *Population) gatherImprintingStats(stats *imprintingStats) {
+	for _, indRef := range p.IndivRefs {
+		silencedFitness, expressedFitness, _, silencedDel, expressedDel := indRef.Indiv.ImprintingLoad()
+		stats.count++
+		stats.silencedFitness += silencedFitness
+		stats.expressedFitness += expressedFitness
+		stats.silencedDel += uint64(silencedDel)
+		stats.expressedDel += uint64(expressedDel)
+	}
+}
+
+// writeImprintingStats writes 1 line to the imprinting file
+func writeImprintingStats(impWriter *os.File, genNum uint32, stats *imprintingStats) {
+	var meanExpFitness, meanSilFitness, meanExpDel, meanSilDel float64
+	if stats.count > 0 {
+		n := float64(stats.count)
+		meanExpFitness = stats.expressedFitness / n
+		meanSilFitness = stats.silencedFitness / n
+		meanExpDel = float64(stats.expressedDel) / n
+		meanSilDel = float64(stats.silencedDel) / n
+	}
+	// If you change this line, you must also change the header in writeImprintingHeader()
+	fmt.Fprintf(impWriter, "%d  %v  %v  %v  %v\n", genNum, meanExpFitness, meanSilFitness, meanExpDel, meanSilDel)
+}
+
+// writeImprintingHeader writes the header of the imprinting file
+func writeImprintingHeader(impWriter *os.File) {
+	fmt.Fprintln(impWriter, "# Generation  Mean-expressed-fitness-effect  Mean-silenced-fitness-effect  Mean-expressed-deleterious  Mean-silenced-deleterious")
+}
+
+// ReportImprinting writes the mean mutation load of the expressed copies of the imprinted regions, and of the silenced copies (the hidden load).
+func (p *Population) ReportImprinting(genNum uint32) {
+	if impWriter := config.FMgr.GetFile(config.IMPRINTING_FILENAME, p.TribeNum); impWriter != nil {
+		config.Verbose(5, "Writing to file %v", config.IMPRINTING_FILENAME)
+		stats := &imprintingStats{}
+		p.gatherImprintingStats(stats)
+		writeImprintingStats(impWriter, genNum, stats)
+	}
+}
@@ -0,0 +1,48 @@
+package pop
+
+import (
+	"math"
+	"testing"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/dna"
+)
+
+// setImprintingModels sets the config values and models an individual with a maternally expressed imprinted region (LBs 2-3 of chromosome 1) needs
+func setImprintingModels() {
+	config.Cfg = &config.Config{}
+	config.Cfg.Population.Ploidy = 2
+	config.Cfg.Population.Haploid_chromosome_number = 2
+	config.Cfg.Mutations.Recessive_hetero_expression = 0.1
+	config.Cfg.Mutations.Dominant_hetero_expression = 0.9
+	dna.Mdl = &dna.Models{CalcHeteroExpression: dna.FixedHeteroExpression}
+	Mdl = &Models{
+		CalcIndivFitness: SumIndivFitness,
+		ImprintedRegions: []ImprintedRegion{{ChromoIndex: 0, Start: 1, End: 2, MaternalExpressed: true}},
+	}
+}
+
+// Checks that a recessive mutation on the only expressed copy of an imprinted LB has its full effect, and a mutation on the silenced copy has none
+func TestImprintingFitness(t *testing.T) {
+	setImprintingModels()
+	ind := IndividualFactory(&PopulationPart{Pop: &Population{LBsPerChromosome: 4}}, false)
+
+	const s = 0.2
+	h := config.Cfg.Mutations.Recessive_hetero_expression
+	expressedMutn := dna.Mutation{Id: 1, Type: dna.DELETERIOUS_RECESSIVE, FitnessEffect: float32(-h * s)}		// LBs store the heterozygous effect
+	silencedMutn := dna.Mutation{Id: 2, Type: dna.DELETERIOUS_DOMINANT, FitnessEffect: float32(-config.Cfg.Mutations.Dominant_hetero_expression * 0.3)}
+	// This is the only exported way to put specific mutations in an LB. The 1st mutation goes on the chromosome from mom, the 2nd on the one from dad.
+	dna.ChrAppendInitialAllelePair(&ind.ChromosomesFromMom[0], &ind.ChromosomesFromDad[0], 1, expressedMutn, silencedMutn)
+
+	fitness := Mdl.CalcIndivFitness(ind) + ind.ImprintingFitnessAdjustment()
+	if math.Abs(fitness - (1.0 - s)) > 1e-6 { t.Errorf("expected fitness %v, got %v", 1.0 - s, fitness) }
+
+	silencedFitness, expressedFitness, _, _, _ := ind.ImprintingLoad()
+	if math.Abs(expressedFitness - (-s)) > 1e-6 { t.Errorf("expected expressed fitness effect %v, got %v", -s, expressedFitness) }
+	if math.Abs(silencedFitness - float64(silencedMutn.FitnessEffect)) > 1e-6 { t.Errorf("expected silenced fitness effect %v, got %v", silencedMutn.FitnessEffect, silencedFitness) }
+
+	// Outside the imprinted region, the same recessive mutation only has its heterozygous effect
+	dna.ChrAppendInitialAllelePair(&ind.ChromosomesFromMom[0], &ind.ChromosomesFromDad[0], 3, expressedMutn, dna.Mutation{Id: 3, Type: dna.NEUTRAL})
+	fitness = Mdl.CalcIndivFitness(ind) + ind.ImprintingFitnessAdjustment()
+	if want := 1.0 - s - h * s; math.Abs(fitness - want) > 1e-6 { t.Errorf("expected fitness %v, got %v", want, fitness) }
+}
@@ -16,27 +16,42 @@ type Individual struct {
 	GenoFitness     float64		// fitness due to genomic mutations
 	PhenoFitness     float64		// fitness due to GenoFitness plus environmental noise and selection noise
 	Dead            bool 		// if true, selection has identified it for elimination
+	Lethal          bool 		// if true, it died because of a lethal mutation (Dead is also true)
+	Sterile         bool 		// if true, it has a sterility mutation, so can not mate
 	NumMutations uint32		// keep a running total of the mutations. This is both mutations and initial alleles.
+	TraitValues []float64		// the value of each quantitative trait under stabilizing selection (only set when environment_model is not none)
 
 	// Note: we currently don't really need to cache these, because p.GetMutationStats caches its values, and the only other function that currently uses these is ind.Report() which only gets called for small populations.
 	//		But it would only save 0.56 MB for 10,000 population, so let's wait and see if we need them cached for more stats in the future.
 	NumDeleterious, NumNeutral, NumFavorable uint32		// cache some of the stats we usually gather
 	NumDelAllele, NumFavAllele uint32		// cache some of the stats we usually gather about initial alleles
+	NumOrganelleDeleterious, NumOrganelleNeutral, NumOrganelleFavorable uint32		// the mutations in the organelle genome, which are not included in the stats above
+	MutnRate float64		// the mean number of new mutations for this individual, which is mutn_rate modified by the mutators it inherited and its parents' germline factors
+	MutnRateFactor float64		// the factor this individual's germline multiplies the mutation rate of its offspring by (only drawn when mutn_rate_heterogeneity > 0, otherwise 1)
+	NumNewMutations uint32		// the number of new mutations this individual got (not inherited)
+	Id, DadId, MomId uint64		// the pedigree ids of this individual and its parents (only set when the pedigree is tracked)
+	Inbreeding float32		// the inbreeding coefficient F of this individual (only set when the pedigree is tracked)
+	MaternalFitness float64		// the fitness of this individual's mother (see maternal_effect_fitness), saved because the parents are freed after mating. Only set when maternal_effect_weight > 0.
+	NumNewPaternal, NumNewMaternal uint32		// the number of new mutations this individual got in the chromosomes from dad and from mom (only counted when paternal_mutn_rate or maternal_mutn_rate is set)
 
 	ChromosomesFromDad []dna.Chromosome
 	ChromosomesFromMom []dna.Chromosome
+	Organelle dna.Chromosome		// the haploid organelle genome (only has LBs when organelle_model is not none)
 }
 
 
 func IndividualFactory(popPart *PopulationPart, _ bool) *Individual {
+	setsFromDad, setsFromMom := numSetsFromEachParent()
 	ind := &Individual{
 		popPart: popPart,
-		ChromosomesFromDad: make([]dna.Chromosome, config.Cfg.Population.Haploid_chromosome_number),
-		ChromosomesFromMom: make([]dna.Chromosome, config.Cfg.Population.Haploid_chromosome_number),
+		MutnRateFactor: 1.0,
+		ChromosomesFromDad: make([]dna.Chromosome, config.Cfg.Population.Haploid_chromosome_number * setsFromDad),
+		ChromosomesFromMom: make([]dna.Chromosome, config.Cfg.Population.Haploid_chromosome_number * setsFromMom),
 	}
 
 	for i := range ind.ChromosomesFromDad { ind.ChromosomesFromDad[i].ChromosomeFactory(popPart.Pop.LBsPerChromosome) }
 	for i := range ind.ChromosomesFromMom { ind.ChromosomesFromMom[i].ChromosomeFactory(popPart.Pop.LBsPerChromosome) }
+	if Mdl.InheritOrganelle != nil { ind.Organelle.ChromosomeFactory(config.Cfg.Organelle.Organelle_num_linkage_subunits) }
 
 	return ind
 }
@@ -49,12 +64,27 @@ func (ind *Individual) Reinitialize() *Individual {
 	ind.GenoFitness = 0.0
 	ind.PhenoFitness = 0.0
 	ind.Dead = false
+	ind.TraitValues = ind.TraitValues[:0]
+	ind.Lethal = false
+	ind.Sterile = false
 	ind.NumMutations = 0
 	ind.NumDeleterious = 0
 	ind.NumNeutral = 0
 	ind.NumFavorable = 0
 	ind.NumDelAllele = 0
 	ind.NumFavAllele = 0
+	ind.NumOrganelleDeleterious = 0
+	ind.NumOrganelleNeutral = 0
+	ind.NumOrganelleFavorable = 0
+	ind.MutnRate = 0.0
+	ind.MutnRateFactor = 1.0
+	ind.NumNewMutations = 0
+	ind.MaternalFitness = 0.0
+	ind.Id, ind.DadId, ind.MomId = 0, 0, 0
+	ind.Inbreeding = 0.0
+	ind.NumNewPaternal = 0
+	ind.NumNewMaternal = 0
+	ind.Organelle.Reinitialize()
 
 	return ind
 }
@@ -73,13 +103,23 @@ func (ind *Individual) Mate(otherInd *Individual, newPopPart *PopulationPart, un
 	actual_offspring := Mdl.CalcNumOffspring(ind, uniformRandom)
 	offspr := make([]*Individual, actual_offspring) 	// temporary slice of the children created
 	for child:=uint32(0); child<actual_offspring; child++ {
-		offspr[child] = ind.OneOffspring(otherInd, newPopPart, uniformRandom)
+		switch {
+		case config.Cfg.Population.Ploidy == 1:
+			offspr[child] = ind.HaploidOffspring(otherInd, child, newPopPart, uniformRandom)
+		case config.Cfg.Population.Ploidy > 2:
+			offspr[child] = ind.PolyploidOffspring(otherInd, newPopPart, uniformRandom)
+		default:
+			offspr[child] = ind.OneOffspring(otherInd, newPopPart, uniformRandom)
+		}
 	}
 
 	// Add mutations to each offspring. Note: this is done after mating is completed for these parents, because as an optimization
 	// we use copy-on-write for the children LBs. I'm not sure that matters.
+	germlineFactor := (ind.MutnRateFactor + otherInd.MutnRateFactor) / 2.0
 	for _, child := range offspr {
-		child.AddMutations(ind.popPart.Pop.LBsPerChromosome, uniformRandom)
+		child.AddMutations(germlineFactor, ind.popPart.Pop.LBsPerChromosome, uniformRandom)
+		if config.Cfg.Selection.Maternal_effect_weight > 0.0 { child.MaternalFitness = otherInd.maternalEffectFitness() }		// otherInd is the mom
+		if Mdl.TrackPedigree { child.DadId, child.MomId = ind.Id, otherInd.Id }
 	}
 
 	return
@@ -118,14 +158,61 @@ func (dad *Individual) OneOffspring(mom *Individual, newPopPart *PopulationPart,
 		offspr.NumFavAllele += favAllele
 	}
 
+	// The organelle genome is copied whole from 1 parent
+	if Mdl.InheritOrganelle != nil {
+		offspr.NumOrganelleDeleterious, offspr.NumOrganelleNeutral, offspr.NumOrganelleFavorable, _, _ = Mdl.InheritOrganelle(dad, mom).Copy(&offspr.Organelle)
+	}
+
 	return offspr
 }
 
 
-// AddMutations adds new mutations to this child right after mating.
-func (child *Individual) AddMutations(lBsPerChromosome uint32, uniformRandom *rand.Rand) {
+// HaploidOffspring returns 1 offspring of this haploid individual and the specified haploid individual. Usually it is a clone of 1 of them (alternating
+// between them by offspring number), but for the fraction haploid_fusion_rate the 2 parents fuse and undergo meiosis.
+func (ind *Individual) HaploidOffspring(otherInd *Individual, offsprNum uint32, newPopPart *PopulationPart, uniformRandom *rand.Rand) *Individual {
+	offspr := newPopPart.GetIndividual()
+	lBsPerChromosome := ind.popPart.Pop.LBsPerChromosome
+	// Check the rate first so we don't use up random numbers when there is no fusion
+	fusion := config.Cfg.Population.Haploid_fusion_rate > 0.0 && uniformRandom.Float64() < config.Cfg.Population.Haploid_fusion_rate
+	parent := ind
+	if offsprNum % 2 == 1 { parent = otherInd }
+
+	for c:=uint32(0); c<ind.GetNumChromosomes(); c++ {
+		var deleterious, neutral, favorable, delAllele, favAllele uint32
+		offsprChr := &offspr.ChromosomesFromDad[c]
+		if fusion {
+			deleterious, neutral, favorable, delAllele, favAllele = dna.Mdl.Crossover(&ind.ChromosomesFromDad[c], &otherInd.ChromosomesFromDad[c], offsprChr, lBsPerChromosome, uniformRandom)
+		} else {
+			deleterious, neutral, favorable, delAllele, favAllele = parent.ChromosomesFromDad[c].Copy(offsprChr)
+		}
+		offspr.NumMutations += deleterious + neutral + favorable + delAllele + favAllele
+		offspr.NumDeleterious += deleterious
+		offspr.NumNeutral += neutral
+		offspr.NumFavorable += favorable
+		offspr.NumDelAllele += delAllele
+		offspr.NumFavAllele += favAllele
+	}
+
+	if Mdl.InheritOrganelle != nil {
+		orgParent := &parent.Organelle
+		if fusion { orgParent = Mdl.InheritOrganelle(ind, otherInd) }
+		offspr.NumOrganelleDeleterious, offspr.NumOrganelleNeutral, offspr.NumOrganelleFavorable, _, _ = orgParent.Copy(&offspr.Organelle)
+	}
+
+	return offspr
+}
+
+
+// AddMutations adds new mutations to this child right after mating. germlineFactor is the mean of the parents' MutnRateFactor.
+func (child *Individual) AddMutations(germlineFactor float64, lBsPerChromosome uint32, uniformRandom *rand.Rand) {
 	// Apply new mutations
-	numMutations := Mdl.CalcNumMutations(uniformRandom)
+	if config.Cfg.Mutations.Fraction_mutator > 0.0 {
+		child.MutnRate = child.CalcMutatorMutnRate() * germlineFactor
+	} else {
+		child.MutnRate = config.Cfg.Mutations.Mutn_rate * germlineFactor
+	}
+	numMutations := Mdl.CalcNumMutations(child, uniformRandom)
+	child.NumNewMutations = numMutations
 	//log.Printf("DEBUG: adding %d mutations to this individual", numMutations)
 	popPart := child.popPart
 	for m:=uint32(1); m<=numMutations; m++ {
@@ -135,20 +222,25 @@ func (child *Individual) AddMutations(lBsPerChromosome uint32, uniformRandom *ra
 		chr := lb / int(lBsPerChromosome) 		// get the chromosome index
 		lbInChr := lb % int(lBsPerChromosome)	// get index of LB within the chromosome
 
-		// Randomly choose the LB from dad or mom to put the mutation in.
+		// Randomly choose the LB from dad or mom to put the mutation in. For polyploids, randomly choose 1 of the chromosome sets (the 1st half are from dad).
 		// Note: AppendMutation() creates a mutation with deleterious/neutral/favorable, dominant/recessive, etc. based on the relevant input parameter rates
 		var mType dna.MutationType
-		if uniformRandom.Intn(2) == 0 {
+		if config.Cfg.Population.Ploidy == 1 {
 			mType = child.ChromosomesFromDad[chr].AppendMutation(lbInChr, popPart.MyUniqueInt.NextInt(), uniformRandom)
+		} else if Mdl.ParentOfOriginMutnRates {
+			mType = child.appendParentOfOriginMutation(chr, lbInChr, uniformRandom)
+		} else if set := uniformRandom.Intn(int(config.Cfg.Population.Ploidy)); set < int(config.Cfg.Population.Ploidy / 2) {
+			mType = child.ChromosomesFromDad[set * int(config.Cfg.Population.Haploid_chromosome_number) + chr].AppendMutation(lbInChr, popPart.MyUniqueInt.NextInt(), uniformRandom)
 		} else {
-			mType = child.ChromosomesFromMom[chr].AppendMutation(lbInChr, popPart.MyUniqueInt.NextInt(), uniformRandom)
+			set -= int(config.Cfg.Population.Ploidy / 2)
+			mType = child.ChromosomesFromMom[set * int(config.Cfg.Population.Haploid_chromosome_number) + chr].AppendMutation(lbInChr, popPart.MyUniqueInt.NextInt(), uniformRandom)
 		}
 		switch mType {
-		case dna.DELETERIOUS_DOMINANT:
+		case dna.DELETERIOUS_DOMINANT, dna.LETHAL_DOMINANT, dna.LETHAL_RECESSIVE, dna.STERILE_DOMINANT, dna.STERILE_RECESSIVE:
 			fallthrough
 		case dna.DELETERIOUS_RECESSIVE:
 			child.NumDeleterious++
-		case dna.NEUTRAL:
+		case dna.NEUTRAL, dna.TRAIT, dna.MUTATOR, dna.LOCAL_ADAPTATION:
 			child.NumNeutral++
 		case dna.FAVORABLE_DOMINANT:
 			fallthrough
@@ -158,13 +250,74 @@ func (child *Individual) AddMutations(lBsPerChromosome uint32, uniformRandom *ra
 	}
 	child.NumMutations += numMutations
 
+	// Apply new inversions. Check the rate first so we don't use up random numbers when inversions are not being modeled.
+	if config.Cfg.Population.Inversion_mutn_rate > 0.0 { child.AddInversions(uniformRandom) }
+
+	if Mdl.InheritOrganelle != nil { child.AddOrganelleMutations(uniformRandom) }
+
 	child.GenoFitness = Mdl.CalcIndivFitness(child) 		// store resulting fitness
+	if Mdl.InheritOrganelle != nil { child.GenoFitness += child.Organelle.SumFitness() }		// the organelle mutations are fully expressed
+	if Mdl.ImprintedRegions != nil {
+		child.GenoFitness += child.ImprintingFitnessAdjustment()
+	}
+	if Mdl.CalcFrequencyDependentEffect != nil {
+		child.GenoFitness += child.FrequencyDependentFitness(popPart.Pop.ParentAlleleFreqs)
+	}
+	if config.Cfg.Mutations.Fraction_local_adaptation > 0.0 {
+		// The fitness is fixed at birth, so if this individual later disperses it keeps the local adaptation fitness of its natal tribe
+		child.GenoFitness += child.LocalAdaptationFitness(popPart.Pop.TribeNum)
+	}
+	if Mdl.StabilizingSelection {
+		child.CalcTraitValues()
+		child.GenoFitness *= EnvironmentalFitness(child.TraitValues, popPart.Pop.Optimum)
+	}
 	if child.GenoFitness <= 0.0 { child.Dead = true }
 
+	// Check for lethal and sterility mutations (new or inherited). Check the fractions first so we don't scan the mutations when they are not being modeled.
+	if config.Cfg.Mutations.Fraction_lethal > 0.0 || config.Cfg.Mutations.Fraction_sterile > 0.0 { child.ApplyLethalSterile() }
+
+	// Draw the germline factor this child will pass on to its own offspring. Check the heterogeneity first so we don't use up random numbers when it is not being modeled.
+	if config.Cfg.Mutations.Mutn_rate_heterogeneity > 0.0 {
+		cv2 := config.Cfg.Mutations.Mutn_rate_heterogeneity * config.Cfg.Mutations.Mutn_rate_heterogeneity
+		child.MutnRateFactor = random.Gamma(uniformRandom, 1.0 / cv2, cv2)
+	}
+
 	return
 }
 
 
+// ApplyLethalSterile marks this individual dead if it has a lethal mutation that takes effect, and sterile if it has a sterility mutation that takes effect.
+func (ind *Individual) ApplyLethalSterile() {
+	for c := range ind.ChromosomesFromDad {
+		// A haploid chromosome is compared with itself, because there is nothing to mask its recessive mutations
+		other := &ind.ChromosomesFromDad[c]
+		if len(ind.ChromosomesFromMom) > 0 { other = &ind.ChromosomesFromMom[c] }
+		lethal, sterile := ind.ChromosomesFromDad[c].LethalSterileStatus(other)
+		if lethal {
+			ind.Lethal = true
+			ind.Dead = true
+		}
+		if sterile { ind.Sterile = true }
+	}
+}
+
+
+// AddInversions adds a poisson distributed number of new inversions (with a mean of inversion_mutn_rate) to random chromosomes of this child.
+// A new inversion that overlaps an inversion already on that chromosome is discarded.
+func (child *Individual) AddInversions(uniformRandom *rand.Rand) {
+	numInversions := random.Poisson(uniformRandom, config.Cfg.Population.Inversion_mutn_rate)
+	for i:=uint32(1); i<=numInversions; i++ {
+		chr := uniformRandom.Intn(int(child.GetNumChromosomes()))
+		invId := child.popPart.MyUniqueInt.NextInt()
+		if uniformRandom.Intn(2) == 0 {
+			child.ChromosomesFromDad[chr].AddRandomInversion(invId, config.Cfg.Population.Max_inversion_length, uniformRandom)
+		} else {
+			child.ChromosomesFromMom[chr].AddRandomInversion(invId, config.Cfg.Population.Max_inversion_length, uniformRandom)
+		}
+	}
+}
+
+
 // AddInitialContrastingAlleles adds numAlleles pairs of contrasting alleles to this individual
 func (ind *Individual) AddInitialContrastingAlleles(numAlleles uint32, uniformRandom *rand.Rand) (uint32, uint32) {
 	// Spread the allele pairs throughout the LBs as evenly as possible: if numAlleles < num_linkage_subunits then skip some LBs to
@@ -225,7 +378,7 @@ type CalcNumOffspringType func(ind *Individual, uniformRandom *rand.Rand) uint32
 // A uniform algorithm for calculating the number of offspring that gives an even distribution between 1 and 2*(Num_offspring*2)-1
 func CalcUniformNumOffspring(ind *Individual, uniformRandom *rand.Rand) uint32 {
 	// If (Num_offspring*2) is 4.5, we want a range from 1-8
-	maxRange := (2 * ind.popPart.Pop.Num_offspring * 2) - 2 		// subtract 2 to get a buffer of 1 at each end
+	maxRange := (2 * ind.popPart.Pop.numOffspring() * 2) - 2 		// subtract 2 to get a buffer of 1 at each end
 	numOffspring := uniformRandom.Float64() * maxRange 		// some float between 0 and maxRange
 	return uint32(random.Round(uniformRandom, numOffspring + 1)) 	// shift it so it is between 1 and maxRange+1, then get to an uint32
 }
@@ -233,7 +386,7 @@ func CalcUniformNumOffspring(ind *Individual, uniformRandom *rand.Rand) uint32 {
 
 // Randomly rounds the desired number of offspring to the integer below or above, proportional to how close it is to each (so the resulting average should be (Num_offspring*2) )
 func CalcSemiFixedNumOffspring(ind *Individual, uniformRandom *rand.Rand) uint32 {
-	return uint32(random.Round(uniformRandom, ind.popPart.Pop.Num_offspring*2))
+	return uint32(random.Round(uniformRandom, ind.popPart.Pop.numOffspring()*2))
 }
 
 
@@ -263,21 +416,26 @@ func CalcFitnessNumOffspring(ind *Individual, uniformRandom *rand.Rand) uint32 {
 
 
 // Algorithms for determining the number of additional mutations a specific offspring should be given
-type CalcNumMutationsType func(uniformRandom *rand.Rand) uint32
+type CalcNumMutationsType func(ind *Individual, uniformRandom *rand.Rand) uint32
 
 // Randomly round Mutn_rate to the uint32 below or above, proportional to how close it is to each (so the resulting average should be Mutn_rate)
-func CalcSemiFixedNumMutations (uniformRandom *rand.Rand) uint32 {
-	numMutations := uint32(random.Round(uniformRandom, config.Cfg.Mutations.Mutn_rate))
+func CalcSemiFixedNumMutations (ind *Individual, uniformRandom *rand.Rand) uint32 {
+	numMutations := uint32(random.Round(uniformRandom, ind.MutnRate))
 	return numMutations
 }
 
-// Use a poisson distribution to choose a number of mutations, with the mean of number of mutations for all individuals being Mutn_rate
-func CalcPoissonNumMutations (uniformRandom *rand.Rand) uint32 {
-	numMutations := uint32(random.Poisson(uniformRandom, config.Cfg.Mutations.Mutn_rate))
-	if config.Cfg.Mutations.Mutn_rate == 0.0 { numMutations = 0 }		// no positive Poisson() will always return 0 for a 0.0 mutn rate
+// Use a poisson distribution to choose a number of mutations, with the mean of number of mutations for the individual being its MutnRate (usually Mutn_rate)
+func CalcPoissonNumMutations (ind *Individual, uniformRandom *rand.Rand) uint32 {
+	numMutations := uint32(random.Poisson(uniformRandom, ind.MutnRate))
+	if ind.MutnRate == 0.0 { numMutations = 0 }		// no positive Poisson() will always return 0 for a 0.0 mutn rate
 	return numMutations
 }
 
+// Use a negative binomial distribution to choose a number of mutations, with mean MutnRate and dispersion mutn_rate_dispersion, so the counts are overdispersed relative to poisson
+func CalcNegativeBinomialNumMutations (ind *Individual, uniformRandom *rand.Rand) uint32 {
+	return random.NegativeBinomial(uniformRandom, ind.MutnRate, config.Cfg.Mutations.Mutn_rate_dispersion)
+}
+
 
 // Algorithms for aggregating all of the individual's mutation fitness factors into a single geno fitness value
 type CalcIndivFitnessType func(ind *Individual) float64
@@ -298,6 +456,16 @@ func SumIndivFitness(ind *Individual) (fitness float64) {
 	return
 }
 
+// ZygosityIndivFitness is like SumIndivFitness, except that a mutation the individual is homozygous for (the same mutation id in the LB
+// from dad and the LB from mom) gets its full fitness effect, instead of twice its heterozygous effect.
+func ZygosityIndivFitness(ind *Individual) (fitness float64) {
+	fitness = SumIndivFitness(ind)
+	for c := range ind.ChromosomesFromDad {
+		fitness += ind.ChromosomesFromDad[c].HomozygousCorrection(&ind.ChromosomesFromMom[c])
+	}
+	return
+}
+
 // Note implemented yet. MultIndivFitness aggregates the fitness factors of all of the mutations using a combination of additive and mutliplicative,
 // based on config.Cfg.Mutations.Multiplicative_weighting
 func MultIndivFitness(_ *Individual) (fitness float64) {
@@ -0,0 +1,137 @@
+package pop
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/dna"
+	"github.com/genetic-algorithms/mendel-go/utils"
+)
+
+// InitialInversion is 1 element of the initial_inversions config parameter
+type InitialInversion struct {
+	ChromoIndex int		// 0 based
+	Start uint32		// 0 based LB index within the chromosome
+	End uint32		// 0 based LB index within the chromosome, inclusive
+	Frequency float64		// the fraction of the chromosome sets in the genesis population that carry this inversion
+}
+
+// ParseInitialInversions parses the config value that is comma-separated 4-tuples chromosome:first-lb:last-lb:frequency (chromosome and LB numbers are 1 based).
+func ParseInitialInversions(inversionsStr string, lBsPerChromosome uint32) (inversions []InitialInversion) {
+	errorStr := "Error: initial_inversions must be like: chromosome:first-lb:last-lb:frequency, ..."
+	if strings.TrimSpace(inversionsStr) == "" { log.Fatal(errorStr) }
+	for i, t := range strings.Split(inversionsStr, ",") {
+		parts := strings.Split(strings.TrimSpace(t), ":")
+		if len(parts) != 4 { log.Fatal(errorStr) }
+		var nums [3]int64
+		for j := 0; j < 3; j++ {
+			var err error
+			nums[j], err = strconv.ParseInt(strings.TrimSpace(parts[j]), 10, 32)
+			if err != nil { log.Fatalf("Error parsing element %d of initial_inversions: %v", i+1, err) }
+		}
+		frequency, err := strconv.ParseFloat(strings.TrimSpace(parts[3]), 64)
+		if err != nil { log.Fatalf("Error parsing frequency in element %d of initial_inversions: %v", i+1, err) }
+
+		if nums[0] < 1 || nums[0] > int64(config.Cfg.Population.Haploid_chromosome_number) { log.Fatalf("Error: chromosome in element %d of initial_inversions must be between 1 and %d, not %d", i+1, config.Cfg.Population.Haploid_chromosome_number, nums[0]) }
+		if nums[1] < 1 || nums[2] <= nums[1] || nums[2] > int64(lBsPerChromosome) { log.Fatalf("Error: first-lb and last-lb in element %d of initial_inversions must satisfy 1 <= first-lb < last-lb <= %d", i+1, lBsPerChromosome) }
+		if frequency <= 0.0 || frequency > 1.0 { log.Fatalf("Error: frequency in element %d of initial_inversions must be > 0.0 and <= 1.0, not %v", i+1, frequency) }
+		newInv := InitialInversion{ChromoIndex: int(nums[0] - 1), Start: uint32(nums[1] - 1), End: uint32(nums[2] - 1), Frequency: frequency}
+
+		// Inversions on the same chromosome can not overlap, because then we could not give each of them its frequency independently
+		for _, inv := range inversions {
+			if inv.ChromoIndex == newInv.ChromoIndex && inv.Start <= newInv.End && newInv.Start <= inv.End { log.Fatalf("Error: element %d of initial_inversions overlaps a previous element on the same chromosome", i+1) }
+		}
+		inversions = append(inversions, newInv)
+	}
+	return
+}
+
+
+// GenerateInitialInversions puts the inversions specified by initial_inversions on the corresponding fraction of the chromosome sets of the genesis population.
+func (p *Population) GenerateInitialInversions(uniformRandom *rand.Rand) {
+	inversions := ParseInitialInversions(config.Cfg.Population.Initial_inversions, p.LBsPerChromosome)
+	numChromoSets := 2 * int(p.GetCurrentSize())
+	for _, initInv := range inversions {
+		inv := dna.Inversion{Id: utils.GlobalUniqueInt.NextInt(), Start: initInv.Start, End: initInv.End}
+		numCarriers := utils.MinInt(utils.RoundInt(float64(numChromoSets) * initInv.Frequency), numChromoSets)
+		config.Verbose(2, "Giving initial inversion %d (chromosome %d, LBs %d-%d) to %d of %d chromosome sets", inv.Id, initInv.ChromoIndex+1, inv.Start+1, inv.End+1, numCarriers, numChromoSets)
+
+		// Choose the chromosome sets randomly. Index j means the chromosome from dad (even) or from mom (odd) of individual j/2.
+		chromoSetIndices := uniformRandom.Perm(numChromoSets)
+		for _, j := range chromoSetIndices[:numCarriers] {
+			ind := p.IndivRefs[j/2].Indiv
+			if j % 2 == 0 {
+				ind.ChromosomesFromDad[initInv.ChromoIndex].AddInversion(inv)
+			} else {
+				ind.ChromosomesFromMom[initInv.ChromoIndex].AddInversion(inv)
+			}
+		}
+	}
+}
+
+
+// inversionStats accumulates the stats of 1 inversion over all of the chromosomes that carry it
+type inversionStats struct {
+	inv dna.Inversion
+	chromoIndex int
+	count uint32		// the number of chromosomes carrying it
+	fitnessEffect float64		// the total fitness effect of the LBs within the inversion on these chromosomes
+	numDeleterious uint64		// the total number of deleterious mutations within the inversion on these chromosomes
+}
+
+// gatherInversionStats adds the inversions carried by the individuals in this population to stats. Returns the number of chromosome sets examined.
+func (p *Population) gatherInversionStats(stats map[uint64]*inversionStats) (numChromoSets uint32) {
+	add := func(c int, chr *dna.Chromosome) {
+		for _, inv := range chr.Inversions {
+			s, ok := stats[inv.Id]
+			if !ok {
+				s = &inversionStats{inv: inv, chromoIndex: c}
+				stats[inv.Id] = s
+			}
+			fitness, numDel := chr.RegionMutationLoad(inv.Start, inv.End)
+			s.count++
+			s.fitnessEffect += float64(fitness)
+			s.numDeleterious += uint64(numDel)
+		}
+	}
+	for _, indRef := range p.IndivRefs {
+		ind := indRef.Indiv
+		for c := range ind.ChromosomesFromDad { add(c, &ind.ChromosomesFromDad[c]) }
+		for c := range ind.ChromosomesFromMom { add(c, &ind.ChromosomesFromMom[c]) }
+	}
+	return 2 * p.GetCurrentSize()
+}
+
+// writeInversionStats writes 1 line to the inversions file for each inversion present in this generation, in order of inversion id.
+func writeInversionStats(invWriter *os.File, genNum uint32, stats map[uint64]*inversionStats, numChromoSets uint32) {
+	if numChromoSets == 0 { return }
+	ids := make([]uint64, 0, len(stats))
+	for id := range stats { ids = append(ids, id) }
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		s := stats[id]
+		// If you change this line, you must also change the header in writeInversionsHeader()
+		fmt.Fprintf(invWriter, "%d  %d  %d  %d  %d  %v  %v  %v\n", genNum, id, s.chromoIndex+1, s.inv.Start+1, s.inv.End+1, float64(s.count)/float64(numChromoSets), s.fitnessEffect/float64(s.count), float64(s.numDeleterious)/float64(s.count))
+	}
+}
+
+// writeInversionsHeader writes the header of the inversions file
+func writeInversionsHeader(invWriter *os.File) {
+	fmt.Fprintln(invWriter, "# Generation  Inversion-id  Chromosome  First-LB  Last-LB  Frequency  Mean-fitness-effect-within  Mean-deleterious-within")
+}
+
+// ReportInversions writes the frequency of each inversion in this population, and the mean mutation load within it on the chromosomes that carry it.
+func (p *Population) ReportInversions(genNum uint32) {
+	if invWriter := config.FMgr.GetFile(config.INVERSIONS_FILENAME, p.TribeNum); invWriter != nil {
+		config.Verbose(5, "Writing to file %v", config.INVERSIONS_FILENAME)
+		stats := make(map[uint64]*inversionStats)
+		numChromoSets := p.gatherInversionStats(stats)
+		writeInversionStats(invWriter, genNum, stats, numChromoSets)
+	}
+}
@@ -0,0 +1,185 @@
+package pop
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/utils"
+)
+
+type LatticeModelType string
+
+const (
+	NO_LATTICE LatticeModelType = "none"
+	LATTICE_1D LatticeModelType = "1d"
+	LATTICE_2D LatticeModelType = "2d"
+)
+
+type DispersalKernelModelType string
+
+const (
+	NEAREST_DISPERSAL     DispersalKernelModelType = "nearest"
+	EXPONENTIAL_DISPERSAL DispersalKernelModelType = "exponential"
+	GAUSSIAN_DISPERSAL    DispersalKernelModelType = "gaussian"
+)
+
+// Algorithms for the relative probability that a disperser moves the given distance
+type DispersalKernelType func(distance float64) float64
+
+// NearestDispersalKernel only allows dispersal to the adjacent demes (the stepping-stone model)
+func NearestDispersalKernel(distance float64) float64 {
+	if distance <= 1.0 { return 1.0 }
+	return 0.0
+}
+
+// ExponentialDispersalKernel makes the probability of dispersing fall off exponentially with distance, with a mean of dispersal_scale
+func ExponentialDispersalKernel(distance float64) float64 {
+	return math.Exp(-distance / config.Cfg.Tribes.Dispersal_scale)
+}
+
+// GaussianDispersalKernel makes the probability of dispersing fall off like a normal distribution with a std dev of dispersal_scale
+func GaussianDispersalKernel(distance float64) float64 {
+	return math.Exp(-distance * distance / (2.0 * config.Cfg.Tribes.Dispersal_scale * config.Cfg.Tribes.Dispersal_scale))
+}
+
+
+// Lattice is the spatial arrangement of the tribes (demes). Tribe n is at column (n-1)%width and row (n-1)/width.
+type Lattice struct {
+	width, height uint32
+	wrap bool		// if true, the edges of the lattice are joined, so it is a ring (1d) or torus (2d)
+	popSizeGradient, reproductiveRateGradient, optimumGradient float64		// how pop_size, reproductive_rate, and the optimum change from the 1st to the last column
+}
+
+// LatticeFactory creates the lattice from the config values
+func LatticeFactory(c *config.Config) *Lattice {
+	l := &Lattice{wrap: c.Tribes.Lattice_wrap}
+	switch LatticeModelType(strings.ToLower(c.Tribes.Lattice_model)) {
+	case LATTICE_1D:
+		l.width, l.height = c.Tribes.Num_tribes, 1
+	case LATTICE_2D:
+		if c.Tribes.Lattice_width == 0 || c.Tribes.Num_tribes % c.Tribes.Lattice_width != 0 { log.Fatalln("Error: for lattice_model==2d, lattice_width must be > 0 and num_tribes must be a multiple of it") }
+		l.width, l.height = c.Tribes.Lattice_width, c.Tribes.Num_tribes / c.Tribes.Lattice_width
+	default:
+		log.Fatalf("Error: unrecognized value for lattice_model: %v", c.Tribes.Lattice_model)
+	}
+	if c.Tribes.Deme_gradients != "" { l.parseGradients(c.Tribes.Deme_gradients) }
+	return l
+}
+
+// parseGradients parses the config value that is comma-separated pairs parameter:change, where parameter is pop_size, reproductive_rate, or optimum
+func (l *Lattice) parseGradients(gradientsStr string) {
+	for i, pair := range strings.Split(gradientsStr, ",") {
+		parts := strings.Split(strings.TrimSpace(pair), ":")
+		if len(parts) != 2 { log.Fatal("Error: deme_gradients must be like: parameter:change,...") }
+		change, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil { log.Fatalf("Error parsing the change in element %d of deme_gradients: %v", i+1, err) }
+		switch strings.ToLower(strings.TrimSpace(parts[0])) {
+		case "pop_size":
+			if change <= -1.0 { log.Fatalln("Error: the pop_size change in deme_gradients must be > -1.0") }
+			l.popSizeGradient = change
+		case "reproductive_rate":
+			if change <= -1.0 { log.Fatalln("Error: the reproductive_rate change in deme_gradients must be > -1.0") }
+			l.reproductiveRateGradient = change
+		case "optimum":
+			if !Mdl.StabilizingSelection { log.Fatalln("Error: an optimum gradient in deme_gradients requires environment_model to not be none") }
+			l.optimumGradient = change
+		default:
+			log.Fatalf("Error: unrecognized parameter %v in deme_gradients. It must be pop_size, reproductive_rate, or optimum.", parts[0])
+		}
+	}
+}
+
+// coords returns the column and row of the tribe
+func (l *Lattice) coords(tribeNum uint32) (x, y uint32) {
+	return (tribeNum-1) % l.width, (tribeNum-1) / l.width
+}
+
+// Distance returns the euclidean distance between 2 tribes, going around the edges if that is shorter and the lattice wraps
+func (l *Lattice) Distance(tribeNum1, tribeNum2 uint32) float64 {
+	x1, y1 := l.coords(tribeNum1)
+	x2, y2 := l.coords(tribeNum2)
+	dx, dy := l.axisDistance(x1, x2, l.width), l.axisDistance(y1, y2, l.height)
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+// axisDistance returns the distance between 2 coordinates along an axis of the given length
+func (l *Lattice) axisDistance(a, b, length uint32) float64 {
+	d := a - b
+	if b > a { d = b - a }
+	if l.wrap && length - d < d { d = length - d }
+	return float64(d)
+}
+
+// gradientPosition returns how far along the gradients the tribe is: 0.0 in the 1st column and 1.0 in the last
+func (l *Lattice) gradientPosition(tribeNum uint32) float64 {
+	if l.width <= 1 { return 0.0 }
+	x, _ := l.coords(tribeNum)
+	return float64(x) / float64(l.width - 1)
+}
+
+// PopSize returns the genesis pop size of the tribe, after applying the pop_size gradient
+func (l *Lattice) PopSize(tribeNum uint32) uint32 {
+	size := float64(config.Cfg.Basic.Pop_size) * (1.0 + l.popSizeGradient * l.gradientPosition(tribeNum))
+	return utils.MaxUint32(uint32(utils.RoundToEven(size)), 2)
+}
+
+// ReproductiveRate returns the reproductive rate of the tribe, after applying the reproductive_rate gradient
+func (l *Lattice) ReproductiveRate(tribeNum uint32) float64 {
+	return config.Cfg.Population.Reproductive_rate * (1.0 + l.reproductiveRateGradient * l.gradientPosition(tribeNum))
+}
+
+// OptimumOffset returns how much the optimum of the tribe's environment differs from the optimum of the environment_model
+func (l *Lattice) OptimumOffset(tribeNum uint32) float64 {
+	return l.optimumGradient * l.gradientPosition(tribeNum)
+}
+
+
+// Disperse moves each individual of each tribe to another tribe with probability dispersal_rate. The destination is chosen with probability
+// proportional to Mdl.DispersalKernel of its distance. This is done after selection, so the dispersers mate in their new tribe.
+func (s *Species) Disperse(uniformRandom *rand.Rand) {
+	if Mdl.DispersalKernel == nil { return }
+	var active []*Population
+	for _, p := range s.Populations {
+		if !p.Done { active = append(active, p) }
+	}
+	immigrants := make([][]IndivRef, len(active))
+	var numDispersers int
+	for i, p := range active {
+		weights := make([]float64, len(active))
+		var totalWeight float64
+		for j, dest := range active {
+			if j == i { continue }
+			weights[j] = Mdl.DispersalKernel(tribeDistance(p.TribeNum, dest.TribeNum))
+			totalWeight += weights[j]
+		}
+		if totalWeight <= 0.0 { continue }
+
+		stayed := make([]IndivRef, 0, len(p.IndivRefs))
+		for _, indRef := range p.IndivRefs {
+			if uniformRandom.Float64() >= config.Cfg.Tribes.Dispersal_rate {
+				stayed = append(stayed, indRef)
+				continue
+			}
+			r := uniformRandom.Float64() * totalWeight
+			dest := 0
+			for j := range weights {
+				if weights[j] <= 0.0 { continue }
+				dest = j
+				r -= weights[j]
+				if r < 0.0 { break }
+			}
+			immigrants[dest] = append(immigrants[dest], indRef)
+			numDispersers++
+		}
+		p.IndivRefs = stayed
+	}
+	for j, p := range active {
+		p.IndivRefs = append(p.IndivRefs, immigrants[j]...)
+		p.clearCachedStats()
+	}
+	config.Verbose(2, "%d individuals dispersed to other tribes", numDispersers)
+}
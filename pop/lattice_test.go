@@ -0,0 +1,82 @@
+package pop
+
+import (
+	"math"
+	"testing"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+)
+
+// Checks the distance between tribes on 1d and 2d lattices, with and without wrapping around the edges
+func TestLatticeDistance(t *testing.T) {
+	line := &Lattice{width: 5, height: 1}
+	ring := &Lattice{width: 5, height: 1, wrap: true}
+	grid := &Lattice{width: 3, height: 2}
+	torus := &Lattice{width: 4, height: 3, wrap: true}
+	tests := []struct {
+		name string
+		l *Lattice
+		tribe1, tribe2 uint32
+		want float64
+	}{
+		{"line same tribe", line, 3, 3, 0.0},
+		{"line neighbors", line, 2, 3, 1.0},
+		{"line ends", line, 1, 5, 4.0},
+		{"ring ends", ring, 1, 5, 1.0},		// wraps around
+		{"ring middle", ring, 1, 3, 2.0},		// shorter not to wrap
+		{"ring farthest", ring, 2, 5, 2.0},
+		{"grid same row", grid, 1, 3, 2.0},
+		{"grid same column", grid, 2, 5, 1.0},
+		{"grid diagonal", grid, 1, 6, math.Sqrt(5.0)},		// tribe 1 is at (0,0) and tribe 6 at (2,1)
+		{"torus both axes wrap", torus, 1, 12, math.Sqrt(2.0)},		// tribe 1 is at (0,0) and tribe 12 at (3,2)
+		{"torus half way", torus, 1, 7, math.Sqrt(5.0)},		// tribe 7 is at (2,1): 2 columns either way, and 1 row
+	}
+	for _, tc := range tests {
+		if got := tc.l.Distance(tc.tribe1, tc.tribe2); math.Abs(got - tc.want) > 1e-12 { t.Errorf("%s: expected distance %v between tribes %d and %d, got %v", tc.name, tc.want, tc.tribe1, tc.tribe2, got) }
+		if got := tc.l.Distance(tc.tribe2, tc.tribe1); math.Abs(got - tc.want) > 1e-12 { t.Errorf("%s: expected distance %v between tribes %d and %d, got %v", tc.name, tc.want, tc.tribe2, tc.tribe1, got) }
+	}
+}
+
+// Checks the distance along 1 axis, which wraps around only when that is shorter and the lattice wraps
+func TestLatticeAxisDistance(t *testing.T) {
+	tests := []struct {
+		wrap bool
+		a, b, length uint32
+		want float64
+	}{
+		{false, 0, 5, 6, 5.0},
+		{true, 0, 5, 6, 1.0},
+		{true, 5, 0, 6, 1.0},
+		{true, 1, 4, 6, 3.0},		// half way, either direction
+		{true, 1, 3, 6, 2.0},
+		{true, 0, 0, 1, 0.0},
+	}
+	for _, tc := range tests {
+		l := &Lattice{wrap: tc.wrap}
+		if got := l.axisDistance(tc.a, tc.b, tc.length); got != tc.want { t.Errorf("%+v: expected axis distance %v, got %v", tc, tc.want, got) }
+	}
+}
+
+// Checks the relative dispersal probabilities of the kernels at several distances
+func TestDispersalKernels(t *testing.T) {
+	config.Cfg = &config.Config{}
+	config.Cfg.Tribes.Dispersal_scale = 2.0
+	tests := []struct {
+		name string
+		kernel DispersalKernelType
+		distance, want float64
+	}{
+		{"nearest", NearestDispersalKernel, 1.0, 1.0},
+		{"nearest diagonal", NearestDispersalKernel, math.Sqrt(2.0), 0.0},
+		{"nearest far", NearestDispersalKernel, 2.0, 0.0},
+		{"exponential", ExponentialDispersalKernel, 1.0, math.Exp(-0.5)},
+		{"exponential at the scale", ExponentialDispersalKernel, 2.0, math.Exp(-1.0)},
+		{"exponential far", ExponentialDispersalKernel, 6.0, math.Exp(-3.0)},
+		{"gaussian", GaussianDispersalKernel, 1.0, math.Exp(-1.0 / 8.0)},
+		{"gaussian at the scale", GaussianDispersalKernel, 2.0, math.Exp(-0.5)},
+		{"gaussian far", GaussianDispersalKernel, 6.0, math.Exp(-4.5)},
+	}
+	for _, tc := range tests {
+		if got := tc.kernel(tc.distance); math.Abs(got - tc.want) > 1e-12 { t.Errorf("%s: expected %v at distance %v, got %v", tc.name, tc.want, tc.distance, got) }
+	}
+}
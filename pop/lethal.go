@@ -0,0 +1,54 @@
+package pop
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+)
+
+// countDeaths sets the stats about the causes of death in this generation. It must be called in Select() after the dead individuals have been
+// marked (numDead of them, at the beginning of p.IndivRefs), but before they are removed.
+func (p *Population) countDeaths(numDead uint32) {
+	p.NumLethalDeaths, p.NumFitnessDeaths, p.NumSelectionDeaths, p.NumSterile = 0, 0, 0, 0
+	for i, indRef := range p.IndivRefs {
+		ind := indRef.Indiv
+		if uint32(i) < numDead {
+			if ind.Lethal {
+				p.NumLethalDeaths++
+			} else if ind.GenoFitness <= 0.0 {
+				p.NumFitnessDeaths++
+			} else {
+				p.NumSelectionDeaths++
+			}
+		} else if ind.Sterile {
+			p.NumSterile++
+		}
+	}
+	config.Verbose(3, "Deaths: lethal mutations: %d, fitness <= 0: %d, selection: %d. Sterile survivors: %d", p.NumLethalDeaths, p.NumFitnessDeaths, p.NumSelectionDeaths, p.NumSterile)
+}
+
+
+// removeSterile returns the parent indices without the sterile individuals, keeping the rest in the same (shuffled) order
+func (p *Population) removeSterile(parentIndices []int) []int {
+	fertile := parentIndices[:0]
+	for _, i := range parentIndices {
+		if !p.IndivRefs[i].Indiv.Sterile { fertile = append(fertile, i) }
+	}
+	return fertile
+}
+
+
+// writeDeathsHeader writes the header of the deaths file
+func writeDeathsHeader(deathsWriter *os.File) {
+	fmt.Fprintln(deathsWriter, "# Generation  Lethal-deaths  Fitness-deaths  Selection-deaths  Sterile")
+}
+
+// ReportDeaths writes the number of individuals that died in this generation from each cause, and the number of surviving individuals that are sterile.
+func (p *Population) ReportDeaths(genNum uint32) {
+	if deathsWriter := config.FMgr.GetFile(config.DEATHS_FILENAME, p.TribeNum); deathsWriter != nil {
+		config.Verbose(5, "Writing to file %v", config.DEATHS_FILENAME)
+		// If you change this line, you must also change the header in writeDeathsHeader()
+		fmt.Fprintf(deathsWriter, "%d  %d  %d  %d  %d\n", genNum, p.NumLethalDeaths, p.NumFitnessDeaths, p.NumSelectionDeaths, p.NumSterile)
+	}
+}
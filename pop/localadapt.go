@@ -0,0 +1,104 @@
+package pop
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/dna"
+)
+
+// isLocallyAdapted returns true for locally adapted mutations
+func isLocallyAdapted(mType dna.MutationType) bool { return mType == dna.LOCAL_ADAPTATION }
+
+// localAdaptationHomeTribe returns the tribe whose environment this mutation is beneficial in. It is derived from the mutation id (which
+// is unique for the whole species), so it does not have to be stored in the mutation and does not use up random numbers.
+func localAdaptationHomeTribe(m dna.Mutation) uint32 {
+	hash := m.Id * 0x9E3779B97F4A7C15		// fibonacci hashing spreads consecutive ids evenly over the tribes
+	return uint32((hash >> 32) % uint64(config.Cfg.Tribes.Num_tribes)) + 1
+}
+
+// countLocallyAdapted is used with SumTrackedMutations() to count the locally adapted mutations
+func countLocallyAdapted(dna.Mutation) float64 { return 1.0 }
+
+// localAdaptationEffect returns the fitness effect of this locally adapted mutation in the environment of the given tribe
+func localAdaptationEffect(m dna.Mutation, tribeNum uint32) float64 {
+	if localAdaptationHomeTribe(m) == tribeNum { return float64(m.FitnessEffect) }
+	return -config.Cfg.Mutations.Local_adaptation_cost * float64(m.FitnessEffect)
+}
+
+// LocalAdaptationFitness returns the total adjustment to this individual's fitness from its locally adapted mutations, in the environment
+// of the given tribe. Each copy of a mutation counts.
+func (ind *Individual) LocalAdaptationFitness(tribeNum uint32) (fitness float64) {
+	effectFunc := func(m dna.Mutation) float64 { return localAdaptationEffect(m, tribeNum) }
+	for c := range ind.ChromosomesFromDad { fitness += ind.ChromosomesFromDad[c].SumTrackedMutations(isLocallyAdapted, effectFunc) }
+	for c := range ind.ChromosomesFromMom { fitness += ind.ChromosomesFromMom[c].SumTrackedMutations(isLocallyAdapted, effectFunc) }
+	return
+}
+
+// numLocallyAdapted returns the number of locally adapted mutations this individual carries that are adapted to the given tribe, and to other tribes
+func (ind *Individual) numLocallyAdapted(tribeNum uint32) (home, foreign float64) {
+	homeFunc := func(m dna.Mutation) float64 {
+		if localAdaptationHomeTribe(m) == tribeNum { return 1.0 }
+		return 0.0
+	}
+	for c := range ind.ChromosomesFromDad {
+		home += ind.ChromosomesFromDad[c].SumTrackedMutations(isLocallyAdapted, homeFunc)
+		foreign += ind.ChromosomesFromDad[c].SumTrackedMutations(isLocallyAdapted, countLocallyAdapted)
+	}
+	for c := range ind.ChromosomesFromMom {
+		home += ind.ChromosomesFromMom[c].SumTrackedMutations(isLocallyAdapted, homeFunc)
+		foreign += ind.ChromosomesFromMom[c].SumTrackedMutations(isLocallyAdapted, countLocallyAdapted)
+	}
+	foreign -= home		// foreign was the total up to here
+	return
+}
+
+
+// localAdaptationStats accumulates the locally adapted mutations of the individuals of 1 or more populations
+type localAdaptationStats struct {
+	count uint32
+	sumHome, sumForeign, sumFitness float64
+}
+
+// gatherLocalAdaptationStats adds the individuals in this population to stats. The home tribe is the one each individual is in now, which after
+// dispersal or recolonization is not necessarily the one it was born in.
+func (p *Population) gatherLocalAdaptationStats(stats *localAdaptationStats) {
+	for _, indRef := range p.IndivRefs {
+		ind := indRef.Indiv
+		stats.count++
+		home, foreign := ind.numLocallyAdapted(p.TribeNum)
+		stats.sumHome += home
+		stats.sumForeign += foreign
+		stats.sumFitness += ind.LocalAdaptationFitness(p.TribeNum)
+	}
+}
+
+// writeLocalAdaptationStats writes 1 line to the local adaptation file
+func writeLocalAdaptationStats(locWriter *os.File, genNum uint32, stats *localAdaptationStats) {
+	var meanHome, meanForeign, meanFitness float64
+	if stats.count > 0 {
+		meanHome = stats.sumHome / float64(stats.count)
+		meanForeign = stats.sumForeign / float64(stats.count)
+		meanFitness = stats.sumFitness / float64(stats.count)
+	}
+	// If you change this line, you must also change the header in writeLocalAdaptationHeader()
+	fmt.Fprintf(locWriter, "%d  %v  %v  %v\n", genNum, meanHome, meanForeign, meanFitness)
+}
+
+// writeLocalAdaptationHeader writes the header of the local adaptation file
+func writeLocalAdaptationHeader(locWriter *os.File) {
+	fmt.Fprintln(locWriter, "# Generation  Mean-home-alleles  Mean-foreign-alleles  Mean-local-fitness")
+}
+
+// ReportLocalAdaptation writes the mean number of locally adapted mutations the individuals of this population carry that are adapted to
+// this tribe and to other tribes, and their mean fitness effect in this tribe. For immigrants this differs from the local adaptation fitness
+// they were given at birth, which is for their natal tribe.
+func (p *Population) ReportLocalAdaptation(genNum uint32) {
+	if locWriter := config.FMgr.GetFile(config.LOCAL_ADAPTATION_FILENAME, p.TribeNum); locWriter != nil {
+		config.Verbose(5, "Writing to file %v", config.LOCAL_ADAPTATION_FILENAME)
+		stats := &localAdaptationStats{}
+		p.gatherLocalAdaptationStats(stats)
+		writeLocalAdaptationStats(locWriter, genNum, stats)
+	}
+}
@@ -0,0 +1,86 @@
+package pop
+
+import (
+	"math"
+	"testing"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/dna"
+)
+
+// setLocalAdaptationConfig sets the config values local adaptation needs, with 3 tribes
+func setLocalAdaptationConfig() {
+	config.Cfg = &config.Config{}
+	config.Cfg.Population.Ploidy = 2
+	config.Cfg.Population.Haploid_chromosome_number = 1
+	config.Cfg.Tribes.Num_tribes = 3
+	config.Cfg.Mutations.Local_adaptation_cost = 0.5
+	Mdl = &Models{}
+}
+
+// findLocallyAdapted returns a locally adapted mutation with the given benefit whose home tribe is tribeNum, with an id > minId
+func findLocallyAdapted(tribeNum uint32, minId uint64, benefit float32) dna.Mutation {
+	for id := minId + 1; ; id++ {
+		m := dna.Mutation{Id: id, Type: dna.LOCAL_ADAPTATION, FitnessEffect: benefit}
+		if localAdaptationHomeTribe(m) == tribeNum { return m }
+	}
+}
+
+// Checks that the home tribe of a mutation is always a valid tribe, depends only on its id, and is spread about evenly over the tribes
+func TestLocalAdaptationHomeTribe(t *testing.T) {
+	setLocalAdaptationConfig()
+	const numIds = 30000
+	counts := make(map[uint32]int)
+	for id := uint64(1); id <= numIds; id++ {
+		home := localAdaptationHomeTribe(dna.Mutation{Id: id})
+		if home < 1 || home > config.Cfg.Tribes.Num_tribes { t.Fatalf("mutation %d has home tribe %d, which is not between 1 and %d", id, home, config.Cfg.Tribes.Num_tribes) }
+		if again := localAdaptationHomeTribe(dna.Mutation{Id: id, FitnessEffect: 0.1}); again != home { t.Errorf("mutation %d has home tribe %d and then %d", id, home, again) }
+		counts[home]++
+	}
+	for tribeNum := uint32(1); tribeNum <= config.Cfg.Tribes.Num_tribes; tribeNum++ {
+		if frac := float64(counts[tribeNum]) / numIds; math.Abs(frac - 1.0/3.0) > 0.01 { t.Errorf("expected about 1/3 of the mutations to have home tribe %d, got %v", tribeNum, frac) }
+	}
+}
+
+// Checks that a locally adapted mutation has its benefit in its home tribe, and -local_adaptation_cost times that in the others
+func TestLocalAdaptationEffect(t *testing.T) {
+	setLocalAdaptationConfig()
+	m := findLocallyAdapted(2, 0, 0.01)
+	tests := []struct {
+		tribeNum uint32
+		cost, want float64
+	}{
+		{2, 0.5, 0.01},
+		{1, 0.5, -0.005},
+		{3, 0.5, -0.005},
+		{1, 1.0, -0.01},		// symmetric antagonistic pleiotropy
+		{3, 0.0, 0.0},		// conditional neutrality
+	}
+	for _, tc := range tests {
+		config.Cfg.Mutations.Local_adaptation_cost = tc.cost
+		if got := localAdaptationEffect(m, tc.tribeNum); math.Abs(got - tc.want) > 1e-9 { t.Errorf("tribe %d, cost %v: expected effect %v, got %v", tc.tribeNum, tc.cost, tc.want, got) }
+	}
+}
+
+// Checks that an individual's local adaptation fitness sums every copy of each mutation, with no dominance
+func TestLocalAdaptationFitness(t *testing.T) {
+	setLocalAdaptationConfig()
+	ind := IndividualFactory(&PopulationPart{Pop: &Population{LBsPerChromosome: 2}}, false)
+	home1 := findLocallyAdapted(1, 0, 0.01)
+	home2 := findLocallyAdapted(2, home1.Id, 0.02)
+	dna.ChrAppendInitialAllelePair(&ind.ChromosomesFromDad[0], &ind.ChromosomesFromMom[0], 0, home1, home1)		// homozygous
+	dna.ChrAppendInitialAllelePair(&ind.ChromosomesFromDad[0], &ind.ChromosomesFromMom[0], 1, home2, dna.Mutation{Id: home2.Id + 1, Type: dna.NEUTRAL})
+
+	tests := []struct {
+		tribeNum uint32
+		want, wantHome, wantForeign float64
+	}{
+		{1, 2*0.01 - 0.5*0.02, 2, 1},
+		{2, -2*0.5*0.01 + 0.02, 1, 2},
+		{3, -2*0.5*0.01 - 0.5*0.02, 0, 3},
+	}
+	for _, tc := range tests {
+		if got := ind.LocalAdaptationFitness(tc.tribeNum); math.Abs(got - tc.want) > 1e-9 { t.Errorf("tribe %d: expected local adaptation fitness %v, got %v", tc.tribeNum, tc.want, got) }
+		if home, foreign := ind.numLocallyAdapted(tc.tribeNum); home != tc.wantHome || foreign != tc.wantForeign { t.Errorf("tribe %d: expected %v home and %v foreign alleles, got %v and %v", tc.tribeNum, tc.wantHome, tc.wantForeign, home, foreign) }
+	}
+}
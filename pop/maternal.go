@@ -0,0 +1,26 @@
+package pop
+
+import (
+	"strings"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/utils"
+)
+
+// maternalEffectFitness returns the fitness of this individual, as a mother, that is passed on to her offspring according to maternal_effect_fitness
+func (mom *Individual) maternalEffectFitness() float64 {
+	if strings.ToLower(config.Cfg.Selection.Maternal_effect_fitness) == "pheno" {
+		// The ups, spps, and partialtrunc selection noise divides the pheno fitness by a random number, so it is unbounded. Clamp it like spps
+		// normalizes it, to 0 - 1 (or her geno fitness, if favorable mutations have made that > 1).
+		return utils.MinFloat64(utils.MaxFloat64(mom.PhenoFitness, 0.0), utils.MaxFloat64(1.0, mom.GenoFitness))
+	}
+	return mom.GenoFitness
+}
+
+// SelectionFitness returns the fitness the selection noise models start from. This is GenoFitness, except that when maternal_effect_weight > 0
+// that fraction of it comes from the mother's fitness instead.
+func (ind *Individual) SelectionFitness() float64 {
+	w := config.Cfg.Selection.Maternal_effect_weight
+	if w <= 0.0 { return ind.GenoFitness }
+	return (1.0 - w) * ind.GenoFitness + w * ind.MaternalFitness
+}
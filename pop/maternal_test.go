@@ -0,0 +1,32 @@
+package pop
+
+import (
+	"testing"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+)
+
+// Checks that the mothers in the genesis population pass on their fitness (1.0 without initial variation) through maternal effects, not 0
+func TestGenesisMaternalFitness(t *testing.T) {
+	config.Cfg = &config.Config{}
+	config.Cfg.Population.Ploidy = 2
+	config.Cfg.Population.Haploid_chromosome_number = 1
+	config.Cfg.Selection.Maternal_effect_weight = 0.9
+	Mdl = &Models{CalcIndivFitness: SumIndivFitness}
+	p := &Population{LBsPerChromosome: 2}
+	part := &PopulationPart{Pop: p}
+	part.Indivs = []*Individual{IndividualFactory(part, false), IndividualFactory(part, false)}
+	p.Parts = []*PopulationPart{part}
+	p.makeAndFillIndivRefs()
+	p.setGenesisFitness()
+
+	for _, fitnessType := range []string{"geno", "pheno"} {
+		config.Cfg.Selection.Maternal_effect_fitness = fitnessType
+		for i, indRef := range p.IndivRefs {
+			if f := indRef.Indiv.maternalEffectFitness(); f != 1.0 { t.Errorf("%s: genesis individual %d has maternal effect fitness %v, expected 1.0", fitnessType, i, f) }
+		}
+	}
+
+	child := &Individual{GenoFitness: 0.8, MaternalFitness: p.IndivRefs[0].Indiv.maternalEffectFitness()}
+	if want := 0.1 * 0.8 + 0.9 * 1.0; child.SelectionFitness() != want { t.Errorf("expected selection fitness %v, got %v", want, child.SelectionFitness()) }
+}
@@ -22,6 +22,7 @@ type MutationRateModelType string
 const (
 	FIXED_MUTN_RATE   MutationRateModelType = "fixed"
 	POISSON_MUTN_RATE MutationRateModelType = "poisson"
+	NEGATIVE_BINOMIAL_MUTN_RATE MutationRateModelType = "negative-binomial"
 )
 
 type SelectionNoiseModelType string
@@ -41,6 +42,7 @@ const (
 	CAPACITY_POPULATON_GROWTH         PopulationGrowthModelType = "capacity"
 	FOUNDERS_POPULATON_GROWTH         PopulationGrowthModelType = "founders"
 	MULTI_BOTTLENECK_POPULATON_GROWTH PopulationGrowthModelType = "multi-bottleneck"
+	TRAJECTORY_POPULATON_GROWTH       PopulationGrowthModelType = "trajectory"
 )
 
 type InitialAlleleModelType string
@@ -50,6 +52,41 @@ const (
 	VARIABLE_FREQ_INITIAL_ALLELES InitialAlleleModelType = "variablefreq"
 )
 
+type EnvironmentModelType string
+
+const (
+	NO_ENVIRONMENT       EnvironmentModelType = "none"
+	CONSTANT_ENVIRONMENT EnvironmentModelType = "constant"
+	LINEAR_ENVIRONMENT   EnvironmentModelType = "linear"
+	STEP_ENVIRONMENT     EnvironmentModelType = "step"
+	CYCLIC_ENVIRONMENT   EnvironmentModelType = "cyclic"
+)
+
+type FrequencyDependentModelType string
+
+const (
+	NO_FREQUENCY_DEPENDENCE       FrequencyDependentModelType = "none"
+	NEGATIVE_FREQUENCY_DEPENDENCE FrequencyDependentModelType = "negative"
+	POSITIVE_FREQUENCY_DEPENDENCE FrequencyDependentModelType = "positive"
+)
+
+type FrequencyDependentMutationsType string
+
+const (
+	INITIAL_ALLELES_FREQUENCY_DEPENDENT FrequencyDependentMutationsType = "initial-alleles"
+	DELETERIOUS_FREQUENCY_DEPENDENT     FrequencyDependentMutationsType = "deleterious"
+	FAVORABLE_FREQUENCY_DEPENDENT       FrequencyDependentMutationsType = "favorable"
+	ALL_FREQUENCY_DEPENDENT             FrequencyDependentMutationsType = "all"
+)
+
+type OrganelleModelType string
+
+const (
+	NO_ORGANELLE       OrganelleModelType = "none"
+	MATERNAL_ORGANELLE OrganelleModelType = "maternal"
+	PATERNAL_ORGANELLE OrganelleModelType = "paternal"
+)
+
 // Models holds pointers to functions that implement the various algorithms chosen by the input file.
 type Models struct {
 	CalcNumOffspring       CalcNumOffspringType
@@ -57,7 +94,22 @@ type Models struct {
 	CalcNumMutations       CalcNumMutationsType
 	ApplySelectionNoise    ApplySelectionNoiseType
 	PopulationGrowth       PopulationGrowthType
+	PopSizeTrajectory      *PopSizeTrajectory // the parsed pop_size_trajectory file (only used when pop_growth_model==trajectory)
 	GenerateInitialAlleles GenerateInitialAllelesType
+	GenerateInitialVariation GenerateInitialVariationType // nil if initial_variation_model is none
+	CalcOptimum            CalcOptimumType
+	StabilizingSelection   bool // true if there is a quantitative trait under stabilizing selection toward the optimum
+	CalcFrequencyDependentEffect CalcFrequencyDependentEffectType // nil if frequency_dependent_model is none
+	IsFrequencyDependent   IsFrequencyDependentType
+	InheritOrganelle       InheritOrganelleType // nil if organelle_model is none
+	ParentOfOriginMutnRates bool // true if new mutations are put in the chromosomes from dad and from mom according to paternal_mutn_rate and maternal_mutn_rate
+	ImprintedRegions        []ImprintedRegion // nil if imprinted_regions is not set
+	TrackPedigree           bool // true if the pedigree and inbreeding coefficients are tracked, because mendel.ped or mendel.inb is being output
+	DemographicEvents       []DemographicEvent // nil if demographic_events is not set
+	RecolonizationWeight    RecolonizationWeightType // nil if recolonization_delay is 0
+	Lattice                 *Lattice // nil if lattice_model is none
+	DispersalKernel         DispersalKernelType // nil if lattice_model is none or dispersal_rate is 0
+	Catastrophe             CatastropheType // nil if catastrophe_rate is 0
 }
 
 // Mdl is the singleton instance of Models that can be accessed throughout the dna package. It gets set in SetModels().
@@ -89,6 +141,12 @@ func SetModels(c *config.Config) {
 	if c.Mutations.Multiplicative_weighting > 0.0 {
 		Mdl.CalcIndivFitness = MultIndivFitness
 		mdlNames = append(mdlNames, "MultIndivFitness")
+	} else if c.Population.Ploidy > 2 {
+		Mdl.CalcIndivFitness = DosageIndivFitness
+		mdlNames = append(mdlNames, "DosageIndivFitness")
+	} else if c.Mutations.Zygosity_fitness {
+		Mdl.CalcIndivFitness = ZygosityIndivFitness
+		mdlNames = append(mdlNames, "ZygosityIndivFitness")
 	} else {
 		Mdl.CalcIndivFitness = SumIndivFitness
 		mdlNames = append(mdlNames, "SumIndivFitness")
@@ -101,6 +159,9 @@ func SetModels(c *config.Config) {
 	case POISSON_MUTN_RATE:
 		Mdl.CalcNumMutations = CalcPoissonNumMutations
 		mdlNames = append(mdlNames, "CalcPoissonNumMutations")
+	case NEGATIVE_BINOMIAL_MUTN_RATE:
+		Mdl.CalcNumMutations = CalcNegativeBinomialNumMutations
+		mdlNames = append(mdlNames, "CalcNegativeBinomialNumMutations")
 	default:
 		log.Fatalf("Error: unrecognized value for mutn_rate_model: %v", c.Mutations.Mutn_rate_model)
 	}
@@ -175,6 +236,16 @@ func SetModels(c *config.Config) {
 		if c.Population.Pop_growth_rate != 0.0 || c.Population.Pop_growth_rate2 != 0.0 || c.Population.Max_pop_size != 0 || c.Population.Bottleneck_generation != 0 || c.Population.Bottleneck_pop_size != 0 {
 			log.Fatalln("When pop_growth_model==multi-bottlenecks you can not use/specify: pop_growth_rate, pop_growth_rate2, max_pop_size, carrying_capacity, bottleneck_generation, bottleneck_pop_size, num_bottleneck_generations")
 		}
+	case TRAJECTORY_POPULATON_GROWTH:
+		Mdl.PopulationGrowth = TrajectoryPopulationGrowth
+		mdlNames = append(mdlNames, "TrajectoryPopulationGrowth")
+		if c.Population.Pop_size_trajectory == "" {
+			log.Fatalln("For pop_growth_model==trajectory pop_size_trajectory must be specified")
+		}
+		if c.Population.Multiple_Bottlenecks != "" {
+			log.Fatalln("multiple_Bottlenecks can only be specified for pop_growth_model==multi-bottlenecks")
+		}
+		Mdl.PopSizeTrajectory = ReadPopSizeTrajectory(c.Population.Pop_size_trajectory, c.Population.Pop_size_trajectory_interpolation, c.Tribes.Num_tribes)
 	default:
 		log.Fatalf("Error: unrecognized value for pop_growth_model: %v", c.Population.Pop_growth_model)
 	}
@@ -206,5 +277,157 @@ func SetModels(c *config.Config) {
 		log.Fatalf("Error: unrecognized value for initial_allele_fitness_model: %v", c.Population.Initial_allele_fitness_model)
 	}
 
+	switch InitialVariationModelType(strings.ToLower(c.Population.Initial_variation_model)) {
+	case NO_INITIAL_VARIATION:
+		// Mdl.GenerateInitialVariation stays nil
+	case COALESCENT_INITIAL_VARIATION:
+		Mdl.GenerateInitialVariation = GenerateCoalescentVariation
+		mdlNames = append(mdlNames, "GenerateCoalescentVariation")
+	default:
+		log.Fatalf("Error: unrecognized value for initial_variation_model: %v", c.Population.Initial_variation_model)
+	}
+
+	switch EnvironmentModelType(strings.ToLower(c.Selection.Environment_model)) {
+	case NO_ENVIRONMENT:
+		Mdl.CalcOptimum = ConstantOptimum // not used
+	case CONSTANT_ENVIRONMENT:
+		Mdl.CalcOptimum = ConstantOptimum
+		Mdl.StabilizingSelection = true
+		mdlNames = append(mdlNames, "ConstantOptimum")
+	case LINEAR_ENVIRONMENT:
+		Mdl.CalcOptimum = LinearOptimum
+		Mdl.StabilizingSelection = true
+		mdlNames = append(mdlNames, "LinearOptimum")
+	case STEP_ENVIRONMENT:
+		if c.Selection.Optimum_period == 0 {
+			log.Fatalln("For environment_model==step optimum_period must be > 0")
+		}
+		Mdl.CalcOptimum = StepOptimum
+		Mdl.StabilizingSelection = true
+		mdlNames = append(mdlNames, "StepOptimum")
+	case CYCLIC_ENVIRONMENT:
+		if c.Selection.Optimum_period == 0 {
+			log.Fatalln("For environment_model==cyclic optimum_period must be > 0")
+		}
+		Mdl.CalcOptimum = CyclicOptimum
+		Mdl.StabilizingSelection = true
+		mdlNames = append(mdlNames, "CyclicOptimum")
+	default:
+		log.Fatalf("Error: unrecognized value for environment_model: %v", c.Selection.Environment_model)
+	}
+
+	// This has to come after the environment model, because the optimum can have a gradient across the lattice
+	if LatticeModelType(strings.ToLower(c.Tribes.Lattice_model)) != NO_LATTICE {
+		Mdl.Lattice = LatticeFactory(c)
+		mdlNames = append(mdlNames, "Lattice")
+		if c.Tribes.Dispersal_rate > 0.0 {
+			switch DispersalKernelModelType(strings.ToLower(c.Tribes.Dispersal_kernel)) {
+			case NEAREST_DISPERSAL:
+				Mdl.DispersalKernel = NearestDispersalKernel
+				mdlNames = append(mdlNames, "NearestDispersalKernel")
+			case EXPONENTIAL_DISPERSAL:
+				Mdl.DispersalKernel = ExponentialDispersalKernel
+				mdlNames = append(mdlNames, "ExponentialDispersalKernel")
+			case GAUSSIAN_DISPERSAL:
+				Mdl.DispersalKernel = GaussianDispersalKernel
+				mdlNames = append(mdlNames, "GaussianDispersalKernel")
+			default:
+				log.Fatalf("Error: unrecognized value for dispersal_kernel: %v", c.Tribes.Dispersal_kernel)
+			}
+		}
+	} else if c.Tribes.Dispersal_rate > 0.0 || c.Tribes.Deme_gradients != "" {
+		log.Fatalln("Error: dispersal_rate and deme_gradients can only be used when lattice_model is not none")
+	}
+
+	switch FrequencyDependentModelType(strings.ToLower(c.Selection.Frequency_dependent_model)) {
+	case NO_FREQUENCY_DEPENDENCE:
+		// Mdl.CalcFrequencyDependentEffect stays nil
+	case NEGATIVE_FREQUENCY_DEPENDENCE:
+		Mdl.CalcFrequencyDependentEffect = NegativeFrequencyDependentEffect
+		mdlNames = append(mdlNames, "NegativeFrequencyDependentEffect")
+	case POSITIVE_FREQUENCY_DEPENDENCE:
+		Mdl.CalcFrequencyDependentEffect = PositiveFrequencyDependentEffect
+		mdlNames = append(mdlNames, "PositiveFrequencyDependentEffect")
+	default:
+		log.Fatalf("Error: unrecognized value for frequency_dependent_model: %v", c.Selection.Frequency_dependent_model)
+	}
+
+	if Mdl.CalcFrequencyDependentEffect != nil {
+		switch FrequencyDependentMutationsType(strings.ToLower(c.Selection.Frequency_dependent_mutations)) {
+		case INITIAL_ALLELES_FREQUENCY_DEPENDENT:
+			Mdl.IsFrequencyDependent = IsInitialAllele
+		case DELETERIOUS_FREQUENCY_DEPENDENT:
+			Mdl.IsFrequencyDependent = IsDeleteriousMutation
+		case FAVORABLE_FREQUENCY_DEPENDENT:
+			Mdl.IsFrequencyDependent = IsFavorableMutation
+		case ALL_FREQUENCY_DEPENDENT:
+			Mdl.IsFrequencyDependent = IsAnyFitnessMutation
+		default:
+			log.Fatalf("Error: unrecognized value for frequency_dependent_mutations: %v", c.Selection.Frequency_dependent_mutations)
+		}
+	}
+
+	if c.Mutations.Paternal_mutn_rate > 0.0 || c.Mutations.Maternal_mutn_rate > 0.0 {
+		Mdl.ParentOfOriginMutnRates = true
+		mdlNames = append(mdlNames, "ParentOfOriginMutnRates")
+	}
+
+	if config.FMgr.IsFile(config.PEDIGREE_FILENAME) || config.FMgr.IsFile(config.INBREEDING_FILENAME) {
+		Mdl.TrackPedigree = true
+		mdlNames = append(mdlNames, "TrackPedigree")
+	}
+
+	if c.Tribes.Demographic_events != "" {
+		Mdl.DemographicEvents = ParseDemographicEvents(c.Tribes.Demographic_events, c.Tribes.Num_tribes)
+		mdlNames = append(mdlNames, "DemographicEvents")
+	}
+
+	if c.Tribes.Recolonization_delay > 0 {
+		switch RecolonizationWeightingType(strings.ToLower(c.Tribes.Recolonization_weighting)) {
+		case DISTANCE_RECOLONIZATION:
+			Mdl.RecolonizationWeight = DistanceRecolonizationWeight
+			mdlNames = append(mdlNames, "DistanceRecolonizationWeight")
+		case FITNESS_RECOLONIZATION:
+			Mdl.RecolonizationWeight = FitnessRecolonizationWeight
+			mdlNames = append(mdlNames, "FitnessRecolonizationWeight")
+		default:
+			log.Fatalf("Error: unrecognized value for recolonization_weighting: %v", c.Tribes.Recolonization_weighting)
+		}
+	}
+
+	if c.Population.Catastrophe_rate > 0.0 {
+		switch CatastropheModelType(strings.ToLower(c.Population.Catastrophe_model)) {
+		case CULL_CATASTROPHE:
+			Mdl.Catastrophe = CullCatastrophe
+			mdlNames = append(mdlNames, "CullCatastrophe")
+		case PENALTY_CATASTROPHE:
+			Mdl.Catastrophe = PenaltyCatastrophe
+			mdlNames = append(mdlNames, "PenaltyCatastrophe")
+		case MIXED_CATASTROPHE:
+			Mdl.Catastrophe = MixedCatastrophe
+			mdlNames = append(mdlNames, "MixedCatastrophe")
+		default:
+			log.Fatalf("Error: unrecognized value for catastrophe_model: %v", c.Population.Catastrophe_model)
+		}
+	}
+
+	if c.Population.Imprinted_regions != "" {
+		Mdl.ImprintedRegions = ParseImprintedRegions(c.Population.Imprinted_regions, c.Population.Num_linkage_subunits / c.Population.Haploid_chromosome_number)
+		mdlNames = append(mdlNames, "ImprintedRegions")
+	}
+
+	switch OrganelleModelType(strings.ToLower(c.Organelle.Organelle_model)) {
+	case NO_ORGANELLE:
+		// Mdl.InheritOrganelle stays nil
+	case MATERNAL_ORGANELLE:
+		Mdl.InheritOrganelle = MaternalOrganelle
+		mdlNames = append(mdlNames, "MaternalOrganelle")
+	case PATERNAL_ORGANELLE:
+		Mdl.InheritOrganelle = PaternalOrganelle
+		mdlNames = append(mdlNames, "PaternalOrganelle")
+	default:
+		log.Fatalf("Error: unrecognized value for organelle_model: %v", c.Organelle.Organelle_model)
+	}
+
 	config.Verbose(1, "Running with these pop models: %v", strings.Join(mdlNames, ", "))
 }
@@ -0,0 +1,88 @@
+package pop
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/dna"
+)
+
+// isMutator returns true for mutator mutations
+func isMutator(mType dna.MutationType) bool { return mType == dna.MUTATOR }
+
+// mutatorLogFactor returns the natural log of the factor a mutator multiplies the mutation rate by
+func mutatorLogFactor(m dna.Mutation) float64 { return float64(m.FitnessEffect) }
+
+// countMutator is used with SumTrackedMutations() to count the mutators
+func countMutator(dna.Mutation) float64 { return 1.0 }
+
+// CalcMutatorMutnRate returns the mutation rate of this individual: mutn_rate multiplied by the factors of all of the mutators it carries
+// (so each copy of a mutator counts), limited by mutator_max_factor.
+func (ind *Individual) CalcMutatorMutnRate() float64 {
+	var logFactor float64
+	for c := range ind.ChromosomesFromDad { logFactor += ind.ChromosomesFromDad[c].SumTrackedMutations(isMutator, mutatorLogFactor) }
+	for c := range ind.ChromosomesFromMom { logFactor += ind.ChromosomesFromMom[c].SumTrackedMutations(isMutator, mutatorLogFactor) }
+	maxLogFactor := math.Log(config.Cfg.Mutations.Mutator_max_factor)
+	logFactor = math.Max(-maxLogFactor, math.Min(maxLogFactor, logFactor))
+	return config.Cfg.Mutations.Mutn_rate * math.Exp(logFactor)
+}
+
+// numMutators returns the number of mutators this individual carries
+func (ind *Individual) numMutators() (num float64) {
+	for c := range ind.ChromosomesFromDad { num += ind.ChromosomesFromDad[c].SumTrackedMutations(isMutator, countMutator) }
+	for c := range ind.ChromosomesFromMom { num += ind.ChromosomesFromMom[c].SumTrackedMutations(isMutator, countMutator) }
+	return
+}
+
+
+// mutatorStats accumulates the mutation rates of the individuals of 1 or more populations
+type mutatorStats struct {
+	count uint32
+	sumMutnRate, minMutnRate, maxMutnRate float64
+	sumMutators float64
+}
+
+func newMutatorStats() *mutatorStats {
+	return &mutatorStats{minMutnRate: math.MaxFloat64}
+}
+
+// gatherMutatorStats adds the individuals in this population to stats
+func (p *Population) gatherMutatorStats(stats *mutatorStats) {
+	for _, indRef := range p.IndivRefs {
+		ind := indRef.Indiv
+		stats.count++
+		stats.sumMutnRate += ind.MutnRate
+		stats.minMutnRate = math.Min(stats.minMutnRate, ind.MutnRate)
+		stats.maxMutnRate = math.Max(stats.maxMutnRate, ind.MutnRate)
+		stats.sumMutators += ind.numMutators()
+	}
+}
+
+// writeMutatorStats writes 1 line to the mutator file
+func writeMutatorStats(mtrWriter *os.File, genNum uint32, stats *mutatorStats) {
+	var meanMutnRate, minMutnRate, meanMutators float64
+	if stats.count > 0 {
+		meanMutnRate = stats.sumMutnRate / float64(stats.count)
+		minMutnRate = stats.minMutnRate
+		meanMutators = stats.sumMutators / float64(stats.count)
+	}
+	// If you change this line, you must also change the header in writeMutatorHeader()
+	fmt.Fprintf(mtrWriter, "%d  %v  %v  %v  %v\n", genNum, meanMutnRate, minMutnRate, stats.maxMutnRate, meanMutators)
+}
+
+// writeMutatorHeader writes the header of the mutator file
+func writeMutatorHeader(mtrWriter *os.File) {
+	fmt.Fprintln(mtrWriter, "# Generation  Mean-mutn-rate  Min-mutn-rate  Max-mutn-rate  Mean-mutators")
+}
+
+// ReportMutators writes the mean, min, and max mutation rate of the individuals of this population, and the mean number of mutators they carry.
+func (p *Population) ReportMutators(genNum uint32) {
+	if mtrWriter := config.FMgr.GetFile(config.MUTATOR_FILENAME, p.TribeNum); mtrWriter != nil {
+		config.Verbose(5, "Writing to file %v", config.MUTATOR_FILENAME)
+		stats := newMutatorStats()
+		p.gatherMutatorStats(stats)
+		writeMutatorStats(mtrWriter, genNum, stats)
+	}
+}
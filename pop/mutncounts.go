@@ -0,0 +1,57 @@
+package pop
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+)
+
+// newMutationCountStats accumulates the number of new mutations of the individuals of 1 or more populations
+type newMutationCountStats struct {
+	count uint32
+	sum, sumSq float64
+	sumFactor float64		// the sum of the germline factors
+}
+
+// gatherNewMutationCountStats adds the individuals in this population to stats
+func (p *Population) gatherNewMutationCountStats(stats *newMutationCountStats) {
+	for _, indRef := range p.IndivRefs {
+		ind := indRef.Indiv
+		num := float64(ind.NumNewMutations)
+		stats.count++
+		stats.sum += num
+		stats.sumSq += num * num
+		stats.sumFactor += ind.MutnRateFactor
+	}
+}
+
+// writeNewMutationCountStats writes 1 line to the new mutation counts file
+func writeNewMutationCountStats(nmcWriter *os.File, genNum uint32, stats *newMutationCountStats) {
+	var mean, variance, dispersionIndex, meanFactor float64
+	if stats.count > 0 {
+		n := float64(stats.count)
+		mean = stats.sum / n
+		if stats.count > 1 { variance = (stats.sumSq - stats.sum * mean) / (n - 1.0) }		// the sample variance
+		if mean > 0.0 { dispersionIndex = variance / mean }
+		meanFactor = stats.sumFactor / n
+	}
+	// If you change this line, you must also change the header in writeNewMutationCountsHeader()
+	fmt.Fprintf(nmcWriter, "%d  %v  %v  %v  %v\n", genNum, mean, variance, dispersionIndex, meanFactor)
+}
+
+// writeNewMutationCountsHeader writes the header of the new mutation counts file
+func writeNewMutationCountsHeader(nmcWriter *os.File) {
+	fmt.Fprintln(nmcWriter, "# Generation  Mean-new-mutations  Variance-new-mutations  Index-of-dispersion  Mean-germline-factor")
+}
+
+// ReportNewMutationCounts writes the mean and realized variance of the number of new mutations of the individuals of this population.
+// An index of dispersion (variance/mean) above 1 means the counts are overdispersed relative to poisson.
+func (p *Population) ReportNewMutationCounts(genNum uint32) {
+	if nmcWriter := config.FMgr.GetFile(config.NEW_MUTATION_COUNTS_FILENAME, p.TribeNum); nmcWriter != nil {
+		config.Verbose(5, "Writing to file %v", config.NEW_MUTATION_COUNTS_FILENAME)
+		stats := &newMutationCountStats{}
+		p.gatherNewMutationCountStats(stats)
+		writeNewMutationCountStats(nmcWriter, genNum, stats)
+	}
+}
@@ -0,0 +1,75 @@
+package pop
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/dna"
+)
+
+// appendParentOfOriginMutation adds a new mutation to the specified LB of either the chromosomes from dad or from mom, with probability
+// paternal_mutn_rate/mutn_rate of it being dad's. For polyploids, 1 of that parent's chromosome sets is chosen randomly.
+func (child *Individual) appendParentOfOriginMutation(chr, lbInChr int, uniformRandom *rand.Rand) dna.MutationType {
+	chromosomes := child.ChromosomesFromMom
+	if uniformRandom.Float64() * config.Cfg.Mutations.Mutn_rate < config.Cfg.Mutations.Paternal_mutn_rate {
+		chromosomes = child.ChromosomesFromDad
+		child.NumNewPaternal++
+	} else {
+		child.NumNewMaternal++
+	}
+	if config.Cfg.Population.Ploidy > 2 {
+		chr += uniformRandom.Intn(int(config.Cfg.Population.Ploidy / 2)) * int(config.Cfg.Population.Haploid_chromosome_number)
+	}
+	return chromosomes[chr].AppendMutation(lbInChr, child.popPart.MyUniqueInt.NextInt(), uniformRandom)
+}
+
+
+// mutationOriginStats accumulates the number of mutations by parental origin for the individuals of 1 or more populations
+type mutationOriginStats struct {
+	count uint32
+	newPaternal, newMaternal uint64
+	paternal, maternal uint64		// all of the tracked mutations in the chromosomes from dad and from mom
+}
+
+// gatherMutationOriginStats adds the individuals in this population to stats
+func (p *Population) gatherMutationOriginStats(stats *mutationOriginStats) {
+	for _, indRef := range p.IndivRefs {
+		ind := indRef.Indiv
+		stats.count++
+		stats.newPaternal += uint64(ind.NumNewPaternal)
+		stats.newMaternal += uint64(ind.NumNewMaternal)
+		for c := range ind.ChromosomesFromDad { stats.paternal += uint64(ind.ChromosomesFromDad[c].GetNumMutations()) }
+		for c := range ind.ChromosomesFromMom { stats.maternal += uint64(ind.ChromosomesFromMom[c].GetNumMutations()) }
+	}
+}
+
+// writeMutationOriginStats writes 1 line to the mutation origin file
+func writeMutationOriginStats(oriWriter *os.File, genNum uint32, stats *mutationOriginStats) {
+	var meanNewPat, meanNewMat, meanPat, meanMat float64
+	if stats.count > 0 {
+		n := float64(stats.count)
+		meanNewPat = float64(stats.newPaternal) / n
+		meanNewMat = float64(stats.newMaternal) / n
+		meanPat = float64(stats.paternal) / n
+		meanMat = float64(stats.maternal) / n
+	}
+	// If you change this line, you must also change the header in writeMutationOriginHeader()
+	fmt.Fprintf(oriWriter, "%d  %v  %v  %v  %v\n", genNum, meanNewPat, meanNewMat, meanPat, meanMat)
+}
+
+// writeMutationOriginHeader writes the header of the mutation origin file
+func writeMutationOriginHeader(oriWriter *os.File) {
+	fmt.Fprintln(oriWriter, "# Generation  Mean-new-paternal  Mean-new-maternal  Mean-tracked-paternal  Mean-tracked-maternal")
+}
+
+// ReportMutationOrigin writes the mean number of new mutations this generation, and of all tracked mutations, in the chromosomes from dad and from mom.
+func (p *Population) ReportMutationOrigin(genNum uint32) {
+	if oriWriter := config.FMgr.GetFile(config.MUTATION_ORIGIN_FILENAME, p.TribeNum); oriWriter != nil {
+		config.Verbose(5, "Writing to file %v", config.MUTATION_ORIGIN_FILENAME)
+		stats := &mutationOriginStats{}
+		p.gatherMutationOriginStats(stats)
+		writeMutationOriginStats(oriWriter, genNum, stats)
+	}
+}
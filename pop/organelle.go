@@ -0,0 +1,94 @@
+package pop
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/dna"
+	"github.com/genetic-algorithms/mendel-go/random"
+)
+
+// InheritOrganelleType functions return the parent's organelle genome that the child inherits
+type InheritOrganelleType func(dad, mom *Individual) *dna.Chromosome
+
+// MaternalOrganelle is the usual inheritance of mitochondria
+func MaternalOrganelle(_, mom *Individual) *dna.Chromosome { return &mom.Organelle }
+
+// PaternalOrganelle is used for organelles that are inherited from the dad, e.g. chloroplasts in some plants
+func PaternalOrganelle(dad, _ *Individual) *dna.Chromosome { return &dad.Organelle }
+
+
+// AddOrganelleMutations adds a poisson distributed number of new mutations (with a mean of organelle_mutn_rate) to random LBs of this child's organelle genome.
+func (child *Individual) AddOrganelleMutations(uniformRandom *rand.Rand) {
+	numMutations := random.Poisson(uniformRandom, config.Cfg.Organelle.Organelle_mutn_rate)
+	for m:=uint32(1); m<=numMutations; m++ {
+		lb := uniformRandom.Intn(len(child.Organelle.LinkageBlocks))
+		switch child.Organelle.AppendOrganelleMutation(lb, child.popPart.MyUniqueInt.NextInt(), uniformRandom) {
+		case dna.DELETERIOUS_DOMINANT:
+			child.NumOrganelleDeleterious++
+		case dna.NEUTRAL:
+			child.NumOrganelleNeutral++
+		case dna.FAVORABLE_DOMINANT:
+			child.NumOrganelleFavorable++
+		}
+	}
+}
+
+
+// organelleStats accumulates the organelle genome stats of the individuals of 1 or more populations
+type organelleStats struct {
+	count uint32
+	fitnessEffect float64
+	deleterious, neutral, favorable uint64
+	minDeleterious uint32		// the number of deleterious mutations in the least loaded class. When this increases, Muller's ratchet has clicked.
+	numMinDeleterious uint32		// the number of individuals in the least loaded class
+}
+
+// gatherOrganelleStats adds the organelle genomes of the individuals in this population to stats
+func (p *Population) gatherOrganelleStats(stats *organelleStats) {
+	for _, indRef := range p.IndivRefs {
+		ind := indRef.Indiv
+		if stats.count == 0 || ind.NumOrganelleDeleterious < stats.minDeleterious {
+			stats.minDeleterious = ind.NumOrganelleDeleterious
+			stats.numMinDeleterious = 0
+		}
+		if ind.NumOrganelleDeleterious == stats.minDeleterious { stats.numMinDeleterious++ }
+		stats.count++
+		stats.fitnessEffect += ind.Organelle.SumFitness()
+		stats.deleterious += uint64(ind.NumOrganelleDeleterious)
+		stats.neutral += uint64(ind.NumOrganelleNeutral)
+		stats.favorable += uint64(ind.NumOrganelleFavorable)
+	}
+}
+
+// writeOrganelleStats writes 1 line to the organelle file
+func writeOrganelleStats(orgWriter *os.File, genNum uint32, stats *organelleStats) {
+	var meanFitness, meanDel, meanNeut, meanFav, fractionMin float64
+	if stats.count > 0 {
+		n := float64(stats.count)
+		meanFitness = stats.fitnessEffect / n
+		meanDel = float64(stats.deleterious) / n
+		meanNeut = float64(stats.neutral) / n
+		meanFav = float64(stats.favorable) / n
+		fractionMin = float64(stats.numMinDeleterious) / n
+	}
+	// If you change this line, you must also change the header in writeOrganelleHeader()
+	fmt.Fprintf(orgWriter, "%d  %d  %v  %v  %v  %v  %d  %v\n", genNum, stats.count, meanFitness, meanDel, meanNeut, meanFav, stats.minDeleterious, fractionMin)
+}
+
+// writeOrganelleHeader writes the header of the organelle file
+func writeOrganelleHeader(orgWriter *os.File) {
+	fmt.Fprintln(orgWriter, "# Generation  Pop-size  Mean-fitness-effect  Mean-deleterious  Mean-neutral  Mean-favorable  Min-deleterious  Fraction-min-deleterious")
+}
+
+// ReportOrganelle writes the mutation and fitness stats of the organelle genomes of this population, including the size of the least loaded class (for Muller's ratchet).
+func (p *Population) ReportOrganelle(genNum uint32) {
+	if orgWriter := config.FMgr.GetFile(config.ORGANELLE_FILENAME, p.TribeNum); orgWriter != nil {
+		config.Verbose(5, "Writing to file %v", config.ORGANELLE_FILENAME)
+		stats := &organelleStats{}
+		p.gatherOrganelleStats(stats)
+		writeOrganelleStats(orgWriter, genNum, stats)
+	}
+}
@@ -0,0 +1,151 @@
+package pop
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+)
+
+// nextIndivId is the last pedigree id given to an individual. Ids are only assigned in the single threaded parts of the run (creating the
+// genesis populations and selection), so this does not need to be thread safe.
+var nextIndivId uint64
+
+func newIndivId() uint64 {
+	nextIndivId++
+	return nextIndivId
+}
+
+
+// kinshipMatrix holds the kinship coefficient of each pair of individuals (the probability that alleles drawn at random from each of them
+// are identical by descent) in a population after selection. The kinship of an individual with itself is (1+F)/2, where F is its inbreeding coefficient.
+type kinshipMatrix struct {
+	rows map[uint64]int		// the row of each individual id
+	k [][]float32		// lower triangular: k[i][j] for j <= i. nil for the genesis population, whose individuals are unrelated and not inbred.
+}
+
+// get returns the kinship of the individuals in rows i and j
+func (km *kinshipMatrix) get(i, j int) float64 {
+	if km.k == nil {
+		if i == j { return 0.5 }
+		return 0.0
+	}
+	if j > i { i, j = j, i }
+	return float64(km.k[i][j])
+}
+
+
+// row returns the row of the individual with this id. It is fatal for the id to be missing, because that means the individual came
+// from another tribe, and using the zero value would silently give it the kinship of whoever is in row 0.
+func (km *kinshipMatrix) row(id uint64) int {
+	i, ok := km.rows[id]
+	if !ok { log.Fatalf("Error: individual %d is not in the pedigree of the parent generation of this tribe", id) }
+	return i
+}
+
+
+// initGenesisPedigree gives each individual of the genesis population an id. They have no recorded parents, and are unrelated.
+func (p *Population) initGenesisPedigree() {
+	p.Kinship = &kinshipMatrix{rows: make(map[uint64]int, len(p.IndivRefs))}
+	for i, indRef := range p.IndivRefs {
+		indRef.Indiv.Id = newIndivId()
+		p.Kinship.rows[indRef.Indiv.Id] = i
+	}
+}
+
+
+// updatePedigree is called after selection to give each surviving individual an id, and calculate its inbreeding coefficient and its kinship
+// with each of the other survivors from the kinship of their parents. This uses memory and time proportional to the square of the population size.
+func (p *Population) updatePedigree() {
+	parentKinship := p.parentKinship
+	p.parentKinship = nil		// so the parents' matrix can be gc'd
+	n := len(p.IndivRefs)
+	if n > config.MAX_PEDIGREE_POP_SIZE { log.Fatalf("Error: tribe %d has grown to %d individuals, but the pedigree and inbreeding files can only be output for tribes of at most %d individuals, because the kinship matrix uses memory proportional to the square of the population size", p.TribeNum, n, config.MAX_PEDIGREE_POP_SIZE) }
+	dadRows := make([]int, n)
+	momRows := make([]int, n)
+	p.Kinship = &kinshipMatrix{rows: make(map[uint64]int, n), k: make([][]float32, n)}
+	for i, indRef := range p.IndivRefs {
+		ind := indRef.Indiv
+		ind.Id = newIndivId()
+		p.Kinship.rows[ind.Id] = i
+		dadRows[i] = parentKinship.row(ind.DadId)
+		momRows[i] = parentKinship.row(ind.MomId)
+
+		// The inbreeding coefficient of an individual is the kinship of its parents
+		ind.Inbreeding = float32(parentKinship.get(dadRows[i], momRows[i]))
+		p.Kinship.k[i] = make([]float32, i+1)
+		for j := 0; j < i; j++ {
+			p.Kinship.k[i][j] = float32((parentKinship.get(dadRows[i], dadRows[j]) + parentKinship.get(dadRows[i], momRows[j]) + parentKinship.get(momRows[i], dadRows[j]) + parentKinship.get(momRows[i], momRows[j])) / 4.0)
+		}
+		p.Kinship.k[i][i] = (1.0 + ind.Inbreeding) / 2.0
+	}
+}
+
+
+// isPedigreeGen returns true if the pedigree of this generation should be written
+func isPedigreeGen(genNum uint32) bool {
+	return genNum >= config.Cfg.Computation.Pedigree_first_gen && (config.Cfg.Computation.Pedigree_last_gen == 0 || genNum <= config.Cfg.Computation.Pedigree_last_gen)
+}
+
+// writePedigreeHeader writes the header of the pedigree file
+func writePedigreeHeader(pedWriter *os.File) {
+	fmt.Fprintln(pedWriter, "# Generation  Id  Dad-id  Mom-id  Inbreeding")
+}
+
+// writePedigree writes 1 line for each individual in this population to the pedigree file
+func (p *Population) writePedigree(pedWriter *os.File, genNum uint32) {
+	for _, indRef := range p.IndivRefs {
+		ind := indRef.Indiv
+		// If you change this line, you must also change the header in writePedigreeHeader()
+		fmt.Fprintf(pedWriter, "%d  %d  %d  %d  %v\n", genNum, ind.Id, ind.DadId, ind.MomId, ind.Inbreeding)
+	}
+}
+
+// ReportPedigree writes 1 line for each individual in this population with its id, the ids of its parents (0 in the genesis generation), and its inbreeding coefficient.
+func (p *Population) ReportPedigree(genNum uint32) {
+	if pedWriter := config.FMgr.GetFile(config.PEDIGREE_FILENAME, p.TribeNum); pedWriter != nil && isPedigreeGen(genNum) {
+		config.Verbose(5, "Writing to file %v", config.PEDIGREE_FILENAME)
+		p.writePedigree(pedWriter, genNum)
+	}
+}
+
+
+// inbreedingStats accumulates the inbreeding coefficients of the individuals of 1 or more populations
+type inbreedingStats struct {
+	count uint32
+	sum float64
+	max float32
+}
+
+// gatherInbreedingStats adds the individuals in this population to stats
+func (p *Population) gatherInbreedingStats(stats *inbreedingStats) {
+	for _, indRef := range p.IndivRefs {
+		stats.count++
+		stats.sum += float64(indRef.Indiv.Inbreeding)
+		if indRef.Indiv.Inbreeding > stats.max { stats.max = indRef.Indiv.Inbreeding }
+	}
+}
+
+// writeInbreedingStats writes 1 line to the inbreeding file
+func writeInbreedingStats(inbWriter *os.File, genNum uint32, stats *inbreedingStats) {
+	var mean float64
+	if stats.count > 0 { mean = stats.sum / float64(stats.count) }
+	// If you change this line, you must also change the header in writeInbreedingHeader()
+	fmt.Fprintf(inbWriter, "%d  %v  %v\n", genNum, mean, stats.max)
+}
+
+// writeInbreedingHeader writes the header of the inbreeding file
+func writeInbreedingHeader(inbWriter *os.File) {
+	fmt.Fprintln(inbWriter, "# Generation  Mean-inbreeding  Max-inbreeding")
+}
+
+// ReportInbreeding writes the mean and max inbreeding coefficient of the individuals of this population.
+func (p *Population) ReportInbreeding(genNum uint32) {
+	if inbWriter := config.FMgr.GetFile(config.INBREEDING_FILENAME, p.TribeNum); inbWriter != nil {
+		config.Verbose(5, "Writing to file %v", config.INBREEDING_FILENAME)
+		stats := &inbreedingStats{}
+		p.gatherInbreedingStats(stats)
+		writeInbreedingStats(inbWriter, genNum, stats)
+	}
+}
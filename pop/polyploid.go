@@ -0,0 +1,71 @@
+package pop
+
+import (
+	"math/rand"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/dna"
+)
+
+// For polyploids, ChromosomesFromDad and ChromosomesFromMom each hold ploidy/2 chromosome sets, 1 after the other. So chromosome c of set s
+// is at index s*haploid_chromosome_number+c.
+
+// numSetsFromEachParent returns the number of chromosome sets an individual has in ChromosomesFromDad and ChromosomesFromMom. Haploids only use ChromosomesFromDad.
+func numSetsFromEachParent() (fromDad, fromMom uint32) {
+	if config.Cfg.Population.Ploidy == 1 { return 1, 0 }
+	return config.Cfg.Population.Ploidy / 2, config.Cfg.Population.Ploidy / 2
+}
+
+
+// homologs returns all ploidy copies of chromosome c of this individual
+func (ind *Individual) homologs(c uint32) []*dna.Chromosome {
+	n := config.Cfg.Population.Haploid_chromosome_number
+	homologs := make([]*dna.Chromosome, 0, config.Cfg.Population.Ploidy)
+	for i := c; i < uint32(len(ind.ChromosomesFromDad)); i += n { homologs = append(homologs, &ind.ChromosomesFromDad[i]) }
+	for i := c; i < uint32(len(ind.ChromosomesFromMom)); i += n { homologs = append(homologs, &ind.ChromosomesFromMom[i]) }
+	return homologs
+}
+
+
+// PolyploidOffspring returns 1 offspring of this polyploid person (dad) and the specified person (mom). In each parent, the homologs of each chromosome
+// pair randomly into bivalents, and each bivalent produces 1 chromosome of the gamete (using the crossover model), so the gamete has ploidy/2 sets.
+func (dad *Individual) PolyploidOffspring(mom *Individual, newPopPart *PopulationPart, uniformRandom *rand.Rand) *Individual {
+	offspr := newPopPart.GetIndividual()
+	lBsPerChromosome := dad.popPart.Pop.LBsPerChromosome
+	n := config.Cfg.Population.Haploid_chromosome_number
+
+	meiosis := func(parent *Individual, gamete []dna.Chromosome) {
+		for c := uint32(0); c < n; c++ {
+			homologs := parent.homologs(c)
+			pairing := uniformRandom.Perm(len(homologs))
+			for b := 0; b < len(homologs) / 2; b++ {
+				deleterious, neutral, favorable, delAllele, favAllele := dna.Mdl.Crossover(homologs[pairing[2*b]], homologs[pairing[2*b+1]], &gamete[uint32(b)*n+c], lBsPerChromosome, uniformRandom)
+				offspr.NumMutations += deleterious + neutral + favorable + delAllele + favAllele
+				offspr.NumDeleterious += deleterious
+				offspr.NumNeutral += neutral
+				offspr.NumFavorable += favorable
+				offspr.NumDelAllele += delAllele
+				offspr.NumFavAllele += favAllele
+			}
+		}
+	}
+	meiosis(dad, offspr.ChromosomesFromDad)
+	meiosis(mom, offspr.ChromosomesFromMom)
+
+	if Mdl.InheritOrganelle != nil {
+		offspr.NumOrganelleDeleterious, offspr.NumOrganelleNeutral, offspr.NumOrganelleFavorable, _, _ = Mdl.InheritOrganelle(dad, mom).Copy(&offspr.Organelle)
+	}
+
+	return offspr
+}
+
+
+// DosageIndivFitness is like ZygosityIndivFitness, but for polyploids: each tracked mutation gets the fraction of its full fitness effect that
+// corresponds to the number of copies of it the individual has. See dna.DosageCorrection().
+func DosageIndivFitness(ind *Individual) (fitness float64) {
+	fitness = SumIndivFitness(ind)
+	for c := uint32(0); c < config.Cfg.Population.Haploid_chromosome_number; c++ {
+		fitness += dna.DosageCorrection(ind.homologs(c))
+	}
+	return
+}
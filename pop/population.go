@@ -43,9 +43,17 @@ type Population struct {
 
 	TargetSize uint32        // the target size of this population after selection
 	Done bool				 // true if went extinct or hit its pop max
+	ExtinctGen uint32		 // the generation this pop went extinct in, if it is waiting to be recolonized (only used when recolonization_delay > 0)
+	CatastrophePenalty float64		 // the fraction the number of offspring of this pop is reduced by, thru the mating after generation CatastrophePenaltyEndGen (only used when catastrophe_rate > 0)
+	CatastrophePenaltyEndGen uint32
 	BottleNecks *Bottlenecks // the bottlenecks this pop should go thru
 	Num_offspring float64    // Average number of offspring each individual should have (so need to multiple by 2 to get it for the mating pair). Calculated from config values Fraction_random_death and Reproductive_rate.
 	LBsPerChromosome uint32  // How many linkage blocks in each chromosome. For now the total number of LBs must be an exact multiple of the number of chromosomes
+	Optimum float64          // the optimum value of the quantitative trait in this generation (only used when environment_model is not none)
+	ParentAlleleFreqs map[uint64]float64 // the allele frequencies in the parent generation of the frequency dependent mutations (only used when frequency_dependent_model is not none)
+	Kinship *kinshipMatrix // the kinship of each pair of individuals after selection (only used when the pedigree is tracked)
+	parentKinship *kinshipMatrix // the Kinship of the parent generation, until updatePedigree() has used it
+	TreeSeq *treeSequence // the genealogy of the chromosomes, passed down from the prev pop (only used when the tree sequence is recorded)
 
 	// Stats
 	ActualAvgOffspring float64       // The average number of offspring each individual from last generation actually had in this generation
@@ -61,6 +69,8 @@ type Population struct {
 	MeanNumDeleterious, MeanNumNeutral, MeanNumFavorable  float64       // cache some of the stats we usually gather
 
 	MeanNumDelAllele, MeanNumFavAllele float64       // cache some of the stats we usually gather
+
+	NumLethalDeaths, NumFitnessDeaths, NumSelectionDeaths, NumSterile uint32		// the causes of death in this generation, and the number of sterile survivors. Only gathered when lethal or sterility mutations are enabled.
 }
 
 
@@ -73,6 +83,7 @@ func PopulationFactory(prevPop *Population, genNum, tribeNum, partsPerPop uint32
 	} else {
 		// This is the 1st generation, so set the size from the config param
 		targetSize = config.Cfg.Basic.Pop_size
+		if Mdl.Lattice != nil { targetSize = Mdl.Lattice.PopSize(tribeNum) }
 	}
 	p := &Population{
 		TribeNum: tribeNum,
@@ -89,13 +100,24 @@ func PopulationFactory(prevPop *Population, genNum, tribeNum, partsPerPop uint32
 
 	fertility_factor := 1. - config.Cfg.Selection.Fraction_random_death
 	p.Num_offspring = config.Cfg.Population.Reproductive_rate * fertility_factor 	// the default for Num_offspring is 2
+	if Mdl.Lattice != nil { p.Num_offspring = Mdl.Lattice.ReproductiveRate(tribeNum) * fertility_factor }
 
 	p.LBsPerChromosome = uint32(config.Cfg.Population.Num_linkage_subunits / config.Cfg.Population.Haploid_chromosome_number)	// main.initialize() already confirmed it was a clean multiple
+	if Mdl.StabilizingSelection { p.Optimum = p.calcOptimum(genNum) }
+	if Mdl.CalcFrequencyDependentEffect != nil && prevPop != nil { p.ParentAlleleFreqs = prevPop.calcAlleleFreqs(genNum, Mdl.IsFrequencyDependent) }
+	if Mdl.TrackPedigree && prevPop != nil { p.parentKinship = prevPop.Kinship }
+	if prevPop != nil { p.inheritCatastrophePenalty(prevPop, genNum) }
+	if dna.Mdl.RecordTreeSequence && prevPop != nil {
+		p.TreeSeq = prevPop.TreeSeq
+		p.TreeSeq.genNum = genNum
+	}
 
 	if genNum == 0 {
 		// Create individuals (with no mutations) for the genesis generation. (For subsequent generations, individuals are added to the Population object via Mate().
 		p.Parts = append(p.Parts, PopulationPartFactory(targetSize, p))    // for gen 0 we only need 1 part because that doesn't have offspring added to it during Mate()
 		p.makeAndFillIndivRefs()
+		if Mdl.TrackPedigree { p.initGenesisPedigree() }
+		if dna.Mdl.RecordTreeSequence { p.initGenesisTreeSequence() }
 	} else {
 		for i:=1; i<= cap(p.Parts); i++ { p.Parts = append(p.Parts, PopulationPartFactory(0, p)) }
 		// Mate() will populate PopulationPart with Individuals and run makeAndFillIndivRefs()
@@ -105,11 +127,29 @@ func PopulationFactory(prevPop *Population, genNum, tribeNum, partsPerPop uint32
 }
 
 
+// numOffspring returns the average number of offspring each individual of this pop should have, which is Num_offspring reduced by any catastrophe penalty in effect
+func (p *Population) numOffspring() float64 {
+	return p.Num_offspring * (1.0 - p.CatastrophePenalty)
+}
+
+
+// setGenesisFitness sets the fitness of each individual of the genesis population from its initial alleles and standing variation. They are
+// not selected, so their pheno fitness is the same. This is what their offspring inherit through maternal effects.
+func (p *Population) setGenesisFitness() {
+	for _, indRef := range p.IndivRefs {
+		indRef.Indiv.GenoFitness = Mdl.CalcIndivFitness(indRef.Indiv)
+		indRef.Indiv.PhenoFitness = indRef.Indiv.GenoFitness
+	}
+}
+
+
 // Not currently used, but kept here in case we want to reuse populations - Reinitialize recycles a population object for another generation. This saves freeing and reallocating a lot of objects
 func (p *Population) Reinitialize(prevPop *Population, genNum uint32) *Population {
 	if p.Done { return p }
 	// Reinitialize is never called on the genesis population
 	p.TargetSize = Mdl.PopulationGrowth(prevPop, genNum)
+	p.CatastrophePenalty, p.CatastrophePenaltyEndGen = 0.0, 0
+	p.inheritCatastrophePenalty(prevPop, genNum)
 
 	// Truncate the IndivRefs slice. makeAndFillIndivRefs() will make it again if not big enough.
 	p.IndivRefs = p.IndivRefs[:0]
@@ -145,6 +185,32 @@ func (p *Population) Reinitialize(prevPop *Population, genNum uint32) *Populatio
 }
 
 
+// mutationIdsNeeded returns the size of the range of mutation ids to give to the part that mates numParents parents, so it won't run out of ids.
+// When mutn_rate_model=negative-binomial or mutn_rate_heterogeneity > 0 the number of new mutations per child is overdispersed, so the range
+// is at least the mean plus 8 standard deviations of the total number of new mutations of all of the children.
+func (p *Population) mutationIdsNeeded(numParents int) uint64 {
+	maxMutnRate := config.Cfg.Mutations.Mutn_rate
+	if config.Cfg.Mutations.Fraction_mutator > 0.0 { maxMutnRate *= config.Cfg.Mutations.Mutator_max_factor }		// mutators can raise an individual's mutation rate up to this
+	otherRate := config.Cfg.Population.Inversion_mutn_rate		// new inversions get their ids from this range too
+	if Mdl.InheritOrganelle != nil { otherRate += config.Cfg.Organelle.Organelle_mutn_rate }		// and so do new organelle mutations
+	numChildren := float64(numParents) * p.numOffspring()
+	mean := numChildren * (maxMutnRate + otherRate)
+	numMuts := uint64(mean * 1.5)
+	if numMuts <= 100 { numMuts = numMuts * 2}		// with small number the randomness of Poisson distribution can vary more
+
+	isNegBinomial := MutationRateModelType(strings.ToLower(config.Cfg.Mutations.Mutn_rate_model)) == NEGATIVE_BINOMIAL_MUTN_RATE
+	if isNegBinomial || config.Cfg.Mutations.Mutn_rate_heterogeneity > 0.0 {
+		// The mutation rate of a child is maxMutnRate*g, where g is the mean of its parents' germline factors (mean 1, variance cv^2/2).
+		// Siblings share g, so its variance is counted once for each of the ~2*Num_offspring children of a pair of parents.
+		varG := config.Cfg.Mutations.Mutn_rate_heterogeneity * config.Cfg.Mutations.Mutn_rate_heterogeneity / 2.0
+		varPerChild := maxMutnRate + maxMutnRate * maxMutnRate * varG * 2.0 * math.Max(p.Num_offspring, 1.0) + otherRate
+		if isNegBinomial { varPerChild += maxMutnRate * maxMutnRate * (1.0 + varG) / config.Cfg.Mutations.Mutn_rate_dispersion }
+		numMuts = utils.MaxUint64(numMuts, uint64(mean + 8.0 * math.Sqrt(numChildren * varPerChild)) + 100)
+	}
+	return numMuts
+}
+
+
 // Size returns the current number of individuals in this population
 func (p *Population) GetCurrentSize() uint32 {
 	return uint32(len(p.IndivRefs))
@@ -269,6 +335,7 @@ func (p *Population) Mate(newP *Population, uniformRandom *rand.Rand) {
 
 	// To prepare for mating, create a shuffled slice of indices into the parent population
 	parentIndices := uniformRandom.Perm(int(p.GetCurrentSize()))
+	if config.Cfg.Mutations.Fraction_sterile > 0.0 { parentIndices = p.removeSterile(parentIndices) }
 
 	// Divide parentIndices into segments (whose size is an even number) and schedule a go routine to mate each segment
 	// Note: runtime.GOMAXPROCS(runtime.NumCPU()) is the default, but this statement can be modified to set a different number of CPUs to use
@@ -301,8 +368,7 @@ func (p *Population) Mate(newP *Population, uniformRandom *rand.Rand) {
 			}
 
 			// Choose a range of the mutation id's for this part - have to make sure it won't exceed this
-			numMuts := uint64(float64(endIndex - beginIndex + 1) * p.Num_offspring * config.Cfg.Mutations.Mutn_rate * 1.5)
-			if numMuts <= 100 { numMuts = numMuts * 2}		// with small number the randomness of Poisson distribution can vary more
+			numMuts := p.mutationIdsNeeded(endIndex - beginIndex + 1)
 			//log.Printf("DEBUG: donating %d mutation ids for %d individuals", numMuts, endIndex - beginIndex + 1)
 
 			// Start the concurrent routine for this part of the pop
@@ -335,6 +401,7 @@ func (p *Population) Select(uniformRandom *rand.Rand) {
 	// Calculate noise factor to get pheno fitness of each individual
 	herit := config.Cfg.Selection.Heritability
 	p.EnvironNoise = math.Sqrt(p.PreSelGenoFitnessVariance * (1.0-herit) / herit + math.Pow(config.Cfg.Selection.Non_scaling_noise,2))
+	// Note: the noise models start from SelectionFitness(), which includes the maternal effect (if any)
 	Mdl.ApplySelectionNoise(p, p.EnvironNoise, uniformRandom) 		// this sets PhenoFitness in each of the individuals
 
 	// Sort the indexes of the Indivs array by fitness, and mark the least fit individuals as dead
@@ -360,7 +427,10 @@ func (p *Population) Select(uniformRandom *rand.Rand) {
 	}
 	numDead := p.getNumDead()		// under certain circumstances this could be > the number we wanted to select out
 	p.ReportDeadStats()
+	if config.Cfg.Mutations.Fraction_lethal > 0.0 || config.Cfg.Mutations.Fraction_sterile > 0.0 { p.countDeaths(numDead) }
 	p.IndivRefs = p.IndivRefs[numDead:]		// re-slice IndivRefs to eliminate the dead individuals
+	if Mdl.TrackPedigree { p.updatePedigree() }
+	if dna.Mdl.RecordTreeSequence { p.recordTreeSequence() }
 
 	// We can leave the indivs array sparse (with dead individuals in it), because the IndivRefs array only points to live entries in indivs,
 	// and the indivs array will soon be GC'd or reused.
@@ -376,8 +446,7 @@ func (p *Population) IsDone(doLog bool) bool {
 	if popMaxIsSet && p.GetCurrentSize() >= popMax {
 		if doLog { log.Printf("Tribe %d has reached the max specified value of %d. Stopping this tribe.", p.TribeNum, popMax) }
 		return true
-	} else if (RecombinationType(config.Cfg.Population.Recombination_model) == FULL_SEXUAL && p.GetCurrentSize() < 2) || p.GetCurrentSize() == 0 {
-		// Above checks if we don't have enough individuals to mate
+	} else if p.tooFewToMate() {
 		if doLog { log.Printf("Tribe %d is extinct. Stopping this tribe.", p.TribeNum) }
 		return true
 	} else if aveFit, _, _, _, _ := p.GetFitnessStats(); aveFit < config.Cfg.Computation.Extinction_threshold {
@@ -388,6 +457,11 @@ func (p *Population) IsDone(doLog bool) bool {
 	return false
 }
 
+// tooFewToMate returns true if this population does not have enough individuals to mate
+func (p *Population) tooFewToMate() bool {
+	return (RecombinationType(config.Cfg.Population.Recombination_model) == FULL_SEXUAL && p.GetCurrentSize() < 2) || p.GetCurrentSize() == 0
+}
+
 
 // getNumDead returns the current number of dead individuals in this population
 func (p *Population) getNumDead() uint32 {
@@ -427,7 +501,7 @@ func ApplyFullTruncationNoise(p *Population, envNoise float64, uniformRandom *ra
 		if ind.Dead {
 			ind.PhenoFitness = 0.0
 		} else {
-			ind.PhenoFitness = ind.GenoFitness + uniformRandom.Float64() * envNoise
+			ind.PhenoFitness = ind.SelectionFitness() + uniformRandom.Float64() * envNoise
 		}
 	}
 }
@@ -454,7 +528,7 @@ func ApplyUnrestrictProbNoise(p *Population, envNoise float64, uniformRandom *ra
 			ind.PhenoFitness = 0.0
 		} else {
 			//rnd1 := uniformRandom.Float64()
-			ind.PhenoFitness = ind.GenoFitness + (uniformRandom.Float64() * envNoise)
+			ind.PhenoFitness = ind.SelectionFitness() + (uniformRandom.Float64() * envNoise)
 			//rnd2 := uniformRandom.Float64()
 			ind.PhenoFitness = ind.PhenoFitness / (uniformRandom.Float64() + 1.0e-15)
 		}
@@ -480,7 +554,7 @@ func ApplyProportProbNoise(p *Population, envNoise float64, uniformRandom *rand.
 		if ind.Dead {
 			ind.PhenoFitness = 0.0
 		} else {
-			ind.PhenoFitness = ind.GenoFitness + (uniformRandom.Float64() * envNoise)
+			ind.PhenoFitness = ind.SelectionFitness() + (uniformRandom.Float64() * envNoise)
 		}
 		maxFitness = utils.MaxFloat64(maxFitness, ind.PhenoFitness)
 	}
@@ -515,7 +589,7 @@ func ApplyPartialTruncationNoise(p *Population, envNoise float64, uniformRandom
 		if ind.Dead {
 			ind.PhenoFitness = 0.0
 		} else {
-			ind.PhenoFitness = ind.GenoFitness + (uniformRandom.Float64() * envNoise)
+			ind.PhenoFitness = ind.SelectionFitness() + (uniformRandom.Float64() * envNoise)
 			ind.PhenoFitness = ind.PhenoFitness / (config.Cfg.Selection.Partial_truncation_value + ((1. - config.Cfg.Selection.Partial_truncation_value) * uniformRandom.Float64()))
 		}
 	}
@@ -830,6 +904,58 @@ func (p *Population) ReportInitial() {
 		// Write header for this file
 		fmt.Fprintln(fitWriter, "# Generation  Pop-size  Avg Offspring  Avg-fitness  Min-fitness  Max-fitness  Total Mutns  Mean Mutns  Noise")
 	}
+
+	if invWriter := config.FMgr.GetFile(config.INVERSIONS_FILENAME, p.TribeNum); invWriter != nil {
+		writeInversionsHeader(invWriter)
+	}
+
+	if deathsWriter := config.FMgr.GetFile(config.DEATHS_FILENAME, p.TribeNum); deathsWriter != nil {
+		writeDeathsHeader(deathsWriter)
+	}
+
+	if envWriter := config.FMgr.GetFile(config.ENVIRONMENT_FILENAME, p.TribeNum); envWriter != nil {
+		writeEnvironmentHeader(envWriter)
+	}
+
+	if orgWriter := config.FMgr.GetFile(config.ORGANELLE_FILENAME, p.TribeNum); orgWriter != nil {
+		writeOrganelleHeader(orgWriter)
+	}
+
+	if oriWriter := config.FMgr.GetFile(config.MUTATION_ORIGIN_FILENAME, p.TribeNum); oriWriter != nil {
+		writeMutationOriginHeader(oriWriter)
+	}
+
+	if impWriter := config.FMgr.GetFile(config.IMPRINTING_FILENAME, p.TribeNum); impWriter != nil {
+		writeImprintingHeader(impWriter)
+	}
+
+	if mtrWriter := config.FMgr.GetFile(config.MUTATOR_FILENAME, p.TribeNum); mtrWriter != nil {
+		writeMutatorHeader(mtrWriter)
+	}
+
+	if locWriter := config.FMgr.GetFile(config.LOCAL_ADAPTATION_FILENAME, p.TribeNum); locWriter != nil {
+		writeLocalAdaptationHeader(locWriter)
+	}
+
+	if catWriter := config.FMgr.GetFile(config.CATASTROPHE_FILENAME, p.TribeNum); catWriter != nil {
+		writeCatastropheHeader(catWriter)
+	}
+
+	if nmcWriter := config.FMgr.GetFile(config.NEW_MUTATION_COUNTS_FILENAME, p.TribeNum); nmcWriter != nil {
+		writeNewMutationCountsHeader(nmcWriter)
+	}
+
+	if pedWriter := config.FMgr.GetFile(config.PEDIGREE_FILENAME, p.TribeNum); pedWriter != nil {
+		writePedigreeHeader(pedWriter)
+	}
+
+	if inbWriter := config.FMgr.GetFile(config.INBREEDING_FILENAME, p.TribeNum); inbWriter != nil {
+		writeInbreedingHeader(inbWriter)
+	}
+
+	if fstWriter := config.FMgr.GetFile(config.FST_FILENAME, p.TribeNum); fstWriter != nil && config.MultipleTribes() {
+		writeTribeFstHeader(fstWriter)
+	}
 }
 
 
@@ -886,6 +1012,19 @@ func (p *Population) ReportEachGen(genNum uint32, lastGen bool, totalInterimTime
 		}
 	}
 
+	p.ReportInversions(genNum)
+	p.ReportDeaths(genNum)
+	p.ReportEnvironment(genNum)
+	p.ReportOrganelle(genNum)
+	p.ReportMutationOrigin(genNum)
+	p.ReportImprinting(genNum)
+	p.ReportMutators(genNum)
+	p.ReportLocalAdaptation(genNum)
+	p.ReportNewMutationCounts(genNum)
+	p.ReportPedigree(genNum)
+	p.ReportInbreeding(genNum)
+	if lastGen { p.ReportTreeSequence(genNum) }
+
 	// Note: Species.ReportEachGen() runs Population.CountAlleles()
 }
 
@@ -908,6 +1047,9 @@ type Buckets struct {
 	Favorable []uint32 `json:"favorable"`
 	DelInitialAlleles []uint32 `json:"delInitialAlleles"`
 	FavInitialAlleles []uint32 `json:"favInitialAlleles"`
+	DominanceClasses []float64 `json:"dominanceClasses,omitempty"`		// the midpoint h of each dominance class, only when dominance_model==hs-relationship
+	DeleteriousByDominance [][]uint32 `json:"deleteriousByDominance,omitempty"`		// the deleterious bins split into a row for each dominance class
+	FavorableByDominance [][]uint32 `json:"favorableByDominance,omitempty"`
 }
 
 type NormalizedBuckets struct {
@@ -919,11 +1061,15 @@ type NormalizedBuckets struct {
 	Favorable []float64 `json:"favorable"`
 	DelInitialAlleles []float64 `json:"delInitialAlleles"`
 	FavInitialAlleles []float64 `json:"favInitialAlleles"`
+	DominanceClasses []float64 `json:"dominanceClasses,omitempty"`
+	DeleteriousByDominance [][]float64 `json:"deleteriousByDominance,omitempty"`
+	FavorableByDominance [][]float64 `json:"favorableByDominance,omitempty"`
 }
 
 type DistributionBuckets struct {
 	Generation uint32 `json:"generation"`
 	BinMidpointFitness []float64 `json:"binmidpointfitness"`
+	BinMidpointDominance []float64 `json:"binmidpointdominance,omitempty"`		// the h of the bin midpoint fitness, only when dominance_model==hs-relationship
 	Recessive []float64 `json:"recessive"`
 	Dominant []float64 `json:"dominant"`
 }
@@ -978,22 +1124,36 @@ func (p *Population) outputAlleleBins(genNum, popSize uint32, lastGen bool, alle
 		bucketJson.Bins[i] = uint32(i) + 1
 	}
 
+	// When h depends on the fitness effect of each mutation, also split the del and fav bins by dominance class
+	if dna.Mdl.DominanceFromFitness {
+		bucketJson.DominanceClasses = make([]float64, dominanceClassCount)
+		for i := range bucketJson.DominanceClasses {
+			bucketJson.DominanceClasses[i] = (float64(i) + 0.5) * dominanceClassWidth
+		}
+		bucketJson.DeleteriousByDominance = make([][]uint32, dominanceClassCount)
+		bucketJson.FavorableByDominance = make([][]uint32, dominanceClassCount)
+		for i := 0; i < dominanceClassCount; i++ {
+			bucketJson.DeleteriousByDominance[i] = make([]uint32, bucketCount)
+			bucketJson.FavorableByDominance[i] = make([]uint32, bucketCount)
+		}
+	}
+
 	bucketJson.Deleterious = make([]uint32, bucketCount)
-	deleteriousDom, delDomFitness := fillBuckets(alleles.DeleteriousDom, popSize, bucketCount, bucketJson.Deleterious)
-	deleteriousRec, delRecFitness := fillBuckets(alleles.DeleteriousRec, popSize, bucketCount, bucketJson.Deleterious)
+	deleteriousDom, delDomFitness := fillBuckets(alleles.DeleteriousDom, popSize, bucketCount, bucketJson.Deleterious, bucketJson.DeleteriousByDominance)
+	deleteriousRec, delRecFitness := fillBuckets(alleles.DeleteriousRec, popSize, bucketCount, bucketJson.Deleterious, bucketJson.DeleteriousByDominance)
 
 	// Note: we do this even when there are no neutrals, because the plotting software needs all 0's in that case
 	bucketJson.Neutral = make([]uint32, bucketCount)
-	neutral, _ := fillBuckets(alleles.Neutral, popSize, bucketCount, bucketJson.Neutral)
+	neutral, _ := fillBuckets(alleles.Neutral, popSize, bucketCount, bucketJson.Neutral, nil)
 
 	bucketJson.Favorable = make([]uint32, bucketCount)
-	favorableDom, favDomFitness := fillBuckets(alleles.FavorableDom, popSize, bucketCount, bucketJson.Favorable)
-	favorableRec, favRecFitness := fillBuckets(alleles.FavorableRec, popSize, bucketCount, bucketJson.Favorable)
+	favorableDom, favDomFitness := fillBuckets(alleles.FavorableDom, popSize, bucketCount, bucketJson.Favorable, bucketJson.FavorableByDominance)
+	favorableRec, favRecFitness := fillBuckets(alleles.FavorableRec, popSize, bucketCount, bucketJson.Favorable, bucketJson.FavorableByDominance)
 
 	bucketJson.DelInitialAlleles = make([]uint32, bucketCount)
-	delAllele, delAlleleFitness := fillBuckets(alleles.DelInitialAlleles, popSize, bucketCount, bucketJson.DelInitialAlleles)
+	delAllele, delAlleleFitness := fillBuckets(alleles.DelInitialAlleles, popSize, bucketCount, bucketJson.DelInitialAlleles, nil)
 	bucketJson.FavInitialAlleles = make([]uint32, bucketCount)
-	favAllele, favAlleleFitness := fillBuckets(alleles.FavInitialAlleles, popSize, bucketCount, bucketJson.FavInitialAlleles)
+	favAllele, favAlleleFitness := fillBuckets(alleles.FavInitialAlleles, popSize, bucketCount, bucketJson.FavInitialAlleles, nil)
 
 	// Output allele totals stats that we collected during fillBuckets()
 	totalMutns := deleteriousDom + deleteriousRec + neutral + favorableDom + favorableRec + delAllele + favAllele
@@ -1034,6 +1194,10 @@ func (p *Population) outputAlleleBins(genNum, popSize uint32, lastGen bool, alle
 		bucketJson.Favorable = bucketJson.Favorable[1:]
 		bucketJson.DelInitialAlleles = bucketJson.DelInitialAlleles[1:]
 		bucketJson.FavInitialAlleles = bucketJson.FavInitialAlleles[1:]
+		for i := range bucketJson.DeleteriousByDominance {
+			bucketJson.DeleteriousByDominance[i] = bucketJson.DeleteriousByDominance[i][1:]
+			bucketJson.FavorableByDominance[i] = bucketJson.FavorableByDominance[i][1:]
+		}
 	}
 
 	fileName := fmt.Sprintf("%08d.json", genNum)
@@ -1112,6 +1276,20 @@ func (p *Population) outputNormalizedAlleleBins(bucketJson *Buckets, bucketCount
 		normalizedBucketJson.FavInitialAlleles[i] = float64(bucketJson.FavInitialAlleles[i]) / float64(minorAlleleTotal)
 	}
 
+	if bucketJson.DominanceClasses != nil {
+		normalizedBucketJson.DominanceClasses = bucketJson.DominanceClasses
+		normalizedBucketJson.DeleteriousByDominance = make([][]float64, len(bucketJson.DominanceClasses))
+		normalizedBucketJson.FavorableByDominance = make([][]float64, len(bucketJson.DominanceClasses))
+		for j := range bucketJson.DominanceClasses {
+			normalizedBucketJson.DeleteriousByDominance[j] = make([]float64, normalizedBucketCount)
+			normalizedBucketJson.FavorableByDominance[j] = make([]float64, normalizedBucketCount)
+			for i := uint32(0); i < normalizedBucketCount; i++ {
+				normalizedBucketJson.DeleteriousByDominance[j][i] = float64(bucketJson.DeleteriousByDominance[j][i]) / float64(minorAlleleTotal)
+				normalizedBucketJson.FavorableByDominance[j][i] = float64(bucketJson.FavorableByDominance[j][i]) / float64(minorAlleleTotal)
+			}
+		}
+	}
+
 	newJson, err := json.Marshal(normalizedBucketJson)
 	if err != nil { log.Fatalf("error marshaling normalized allele bins to json: %v", err) }
 
@@ -1122,9 +1300,13 @@ func (p *Population) outputNormalizedAlleleBins(bucketJson *Buckets, bucketCount
 }
 
 
-// fillBuckets takes the number of occurrences of each mutation id, determines which bucket it belongs in, and adds 1 to that bucket
-func fillBuckets(counts map[uint64]dna.Allele, popSize uint32, bucketCount uint32, buckets []uint32) (totalMutns uint64, totalFitness float64) {
-	poolSize := float64(2 * popSize)
+const dominanceClassCount = 10
+const dominanceClassWidth = 0.5 / dominanceClassCount		// h is always in the range 0 - 0.5 with the hs-relationship model
+
+// fillBuckets takes the number of occurrences of each mutation id, determines which bucket it belongs in, and adds 1 to that bucket.
+// If dominanceBuckets is not nil, it also adds 1 to that bucket in the row for the dominance class (h) of the mutation.
+func fillBuckets(counts map[uint64]dna.Allele, popSize uint32, bucketCount uint32, buckets []uint32, dominanceBuckets [][]uint32) (totalMutns uint64, totalFitness float64) {
+	poolSize := float64(config.Cfg.Population.Ploidy * popSize)
 	if !config.Cfg.Computation.Count_duplicate_alleles { poolSize = float64(popSize)}	// in this case, each allele count is a measure of how many individuals it occurred in
 
 	for _, count := range counts {
@@ -1163,6 +1345,17 @@ func fillBuckets(counts map[uint64]dna.Allele, popSize uint32, bucketCount uint3
 		}
 
 		buckets[i] += 1
+
+		if dominanceBuckets != nil {
+			h := dna.HeteroExpression(dna.DELETERIOUS_RECESSIVE, count.FitnessEffect)		// the type only needs to be one that is not neutral or an initial allele
+			j := int(h / dominanceClassWidth)
+			if j < 0 {
+				j = 0
+			} else if j >= len(dominanceBuckets) {
+				j = len(dominanceBuckets) - 1
+			}
+			dominanceBuckets[j][i] += 1
+		}
 	}
 
 	return
@@ -1225,7 +1418,7 @@ func (p *Population) outputAlleleDistribution(genNum, popSize uint32, lastGen bo
 	// Use same variable names as mendel-f90 to make it easier to port those formulas
 	logn := math.Log // can't use log() because there is a package named that
 	exp := math.Exp
-	pow := math.Pow
+	//pow := math.Pow
 	//abs := math.Abs
 	//current_pop_size := int(p.GetCurrentSize())
 	//mutn_sum := float64(p.TotalNumMutations)   // a comment in diagnostices.f90 says this should be the expected number of mutns w/o selection
@@ -1233,10 +1426,6 @@ func (p *Population) outputAlleleDistribution(genNum, popSize uint32, lastGen bo
 	frac_fav_mutn := config.Cfg.Mutations.Frac_fav_mutn
 	tracking_threshold := utils.MaxFloat64(1.0/config.Cfg.Mutations.Genome_size, float64(config.Cfg.Computation.Tracking_threshold))
 	max_fav_fitness_gain := config.Cfg.Mutations.Max_fav_fitness_gain
-	alpha_del := config.Computed.Alpha_del
-	alpha_fav := config.Computed.Alpha_fav
-	gamma_del := config.Computed.Gamma_del
-	gamma_fav := config.Computed.Gamma_fav
 	//del_scale := config.Computed.Del_scale
 
 	// Compute the bin widths
@@ -1253,12 +1442,13 @@ func (p *Population) outputAlleleDistribution(genNum, popSize uint32, lastGen bo
 	// Two differences between diagnostices.f90 and here: they put del info in the 1st 50 elements of the arrays and fav info in the 2nd 50,
 	// and they have a 2nd dimension to the array to hold both recessive and dominant info. We use separate arrays for all 4 of those aspects.
 	var del_refr_bins, fav_refr_bins [51]float64	// we are ignoring the 0th element to match fortran
+	// The fraction of new mutations expected in each bin comes from the fitness effect model (mendel-f90 always used weibull)
 	x0 := 0.0
 	y0 := 0.0
 	for k := 1; k <= 50; k++ {
-		x1 := pow(del_bin_width * float64(k) / alpha_del, 1. / gamma_del)
+		x1 := dna.Mdl.DelFitnessLogCDF(del_bin_width * float64(k))
 		del_refr_bins[k] = (1. - frac_fav_mutn) * mutn_sum * (x1 - x0)
-		y1 := pow(fav_bin_width * float64(k) / alpha_fav, 1. / gamma_fav)
+		y1 := dna.Mdl.FavFitnessLogCDF(fav_bin_width * float64(k))
 		fav_refr_bins[k] = frac_fav_mutn * mutn_sum * (y1 - y0)
 		x0 = x1
 		y0 = y1
@@ -1304,6 +1494,7 @@ func (p *Population) outputAlleleDistribution(genNum, popSize uint32, lastGen bo
 	x := 1. - config.Cfg.Mutations.Fraction_neutral
 	if x == 0 { x = 1. }	// don't scale data if fraction_neutral = 1
 	fraction_recessive := config.Cfg.Mutations.Fraction_recessive
+	if dna.Mdl.DominanceFromFitness { fraction_recessive = 1.0 }	// every mutation with a non-zero fitness effect has h < 0.5, so is classified as recessive
 	for k := 1; k <= 50; k++ {
 		// Deleterious
 		if del_refr_bins[k] > 0. && fraction_recessive > 0.{
@@ -1393,6 +1584,10 @@ func (p *Population) outputAlleleDistribution(genNum, popSize uint32, lastGen bo
 			bucketJson.Dominant[k-1] = del_dom_fitness_bins[k]
 			//fmt.Fprintf(alleleWriter, "%v  %v  %v  %v\n", del_bin_fitness_midpoint[k], del_rec_fitness_bins[k], del_dom_fitness_bins[k], del_bin_fitness_boxwidth[k])
 		}
+		if dna.Mdl.DominanceFromFitness {
+			bucketJson.BinMidpointDominance = make([]float64, 50)
+			for k := 1; k <= 50; k++ { bucketJson.BinMidpointDominance[k-1] = dna.HeteroExpression(dna.DELETERIOUS_RECESSIVE, float32(-del_bin_fitness_midpoint[k])) }
+		}
 		newJson, err := json.Marshal(bucketJson)
 		if err != nil { log.Fatalf("error marshaling allele distribution bins to json: %v", err)	}
 		if _, err := alleleWriter.Write(newJson); err != nil { log.Fatalf("error writing alleles to %v: %v", delFileName, err) }
@@ -1411,6 +1606,10 @@ func (p *Population) outputAlleleDistribution(genNum, popSize uint32, lastGen bo
 			bucketJson.Dominant[k-1] = fav_dom_fitness_bins[k]
 			//fmt.Fprintf(alleleWriter, "%v  %v  %v  %v %v\n", fav_bin_fitness_midpoint[k], fav_rec_fitness_bins[k], fav_dom_fitness_bins[k], fav_bin_fitness_boxwidth[k], fav_refr_bins[k])
 		}
+		if dna.Mdl.DominanceFromFitness {
+			bucketJson.BinMidpointDominance = make([]float64, 50)
+			for k := 1; k <= 50; k++ { bucketJson.BinMidpointDominance[k-1] = dna.HeteroExpression(dna.FAVORABLE_RECESSIVE, float32(fav_bin_fitness_midpoint[k])) }
+		}
 		newJson, err := json.Marshal(bucketJson)
 		if err != nil { log.Fatalf("error marshaling allele distribution bins to json: %v", err)	}
 		if _, err := alleleWriter.Write(newJson); err != nil { log.Fatalf("error writing alleles to %v: %v", favFileName, err) }
@@ -0,0 +1,93 @@
+package pop
+
+import (
+	"log"
+	"math/rand"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+)
+
+type RecolonizationWeightingType string
+
+const (
+	DISTANCE_RECOLONIZATION RecolonizationWeightingType = "distance"
+	FITNESS_RECOLONIZATION  RecolonizationWeightingType = "fitness"
+)
+
+// Algorithms for weighting how likely each surviving tribe is to be the source of each migrant that recolonizes an extinct tribe
+type RecolonizationWeightType func(extinct, source *Population) float64
+
+// DistanceRecolonizationWeight favors the tribes closest to the extinct tribe: the weight is 1/distance
+func DistanceRecolonizationWeight(extinct, source *Population) float64 {
+	return 1.0 / tribeDistance(extinct.TribeNum, source.TribeNum)
+}
+
+// FitnessRecolonizationWeight favors the fittest tribes: the weight is the mean fitness of the source tribe
+func FitnessRecolonizationWeight(_, source *Population) float64 {
+	aveFit, _, _, _, _ := source.GetFitnessStats()
+	if aveFit < 0.0 { return 0.0 }
+	return aveFit
+}
+
+// tribeDistance returns the distance between 2 tribes on the lattice, or if there is no lattice, with the tribes arranged in a line in the order of their tribe numbers
+func tribeDistance(tribeNum1, tribeNum2 uint32) float64 {
+	if Mdl.Lattice != nil { return Mdl.Lattice.Distance(tribeNum1, tribeNum2) }
+	if tribeNum1 > tribeNum2 { return float64(tribeNum1 - tribeNum2) }
+	return float64(tribeNum2 - tribeNum1)
+}
+
+
+// RecolonizeExtinctTribes refounds each tribe that went extinct at least recolonization_delay generations ago with num_recolonizers migrants
+// from the surviving tribes. It is called right after selection, so the migrants are reported as the new tribe's population in this generation.
+func (s *Species) RecolonizeExtinctTribes(genNum uint32, uniformRandom *rand.Rand) {
+	if Mdl.RecolonizationWeight == nil { return }
+	for _, p := range s.Populations {
+		if !p.Done || p.ExtinctGen == 0 || genNum < p.ExtinctGen + config.Cfg.Tribes.Recolonization_delay { continue }
+		migrants, sourceTribes := s.drawMigrants(p, config.Cfg.Tribes.Num_recolonizers, uniformRandom)
+		if len(migrants) == 0 {
+			log.Printf("Gen %d: tribe %d can not be recolonized yet, because no other tribe has individuals to spare", genNum, p.TribeNum)
+			continue
+		}
+		p.IndivRefs = migrants
+		p.Done = false
+		p.ExtinctGen = 0
+		if Mdl.StabilizingSelection { p.Optimum = p.calcOptimum(genNum) }
+		p.NumLethalDeaths, p.NumFitnessDeaths, p.NumSelectionDeaths, p.NumSterile = 0, 0, 0, 0
+		p.clearCachedStats()
+		log.Printf("Gen %d: recolonized tribe %d with %d migrants from tribes %v", genNum, p.TribeNum, len(migrants), sourceTribes)
+	}
+}
+
+// drawMigrants removes up to num random individuals from the surviving tribes to refound the extinct tribe. The source tribe of each migrant is
+// chosen according to Mdl.RecolonizationWeight, and a source tribe is never left with fewer than 2 individuals. Returns the migrants and the tribe each came from.
+func (s *Species) drawMigrants(extinct *Population, num uint32, uniformRandom *rand.Rand) (migrants []IndivRef, sourceTribes []uint32) {
+	var sources []*Population
+	var weights []float64
+	for _, p := range s.Populations {
+		if p.Done || p == extinct { continue }
+		sources = append(sources, p)
+		weights = append(weights, Mdl.RecolonizationWeight(extinct, p))
+	}
+
+	for m := uint32(0); m < num; m++ {
+		var totalWeight float64
+		for i, p := range sources {
+			if p.GetCurrentSize() > 2 { totalWeight += weights[i] }
+		}
+		if totalWeight <= 0.0 { break }
+		r := uniformRandom.Float64() * totalWeight
+		var source *Population
+		for i, p := range sources {
+			if p.GetCurrentSize() <= 2 { continue }
+			source = p
+			r -= weights[i]
+			if r < 0.0 { break }
+		}
+		i := uniformRandom.Intn(len(source.IndivRefs))
+		migrants = append(migrants, source.IndivRefs[i])
+		source.IndivRefs = append(source.IndivRefs[:i], source.IndivRefs[i+1:]...)
+		source.clearCachedStats()
+		sourceTribes = append(sourceTribes, source.TribeNum)
+	}
+	return
+}
@@ -37,6 +37,9 @@ func (s *Species) Initialize(maxGenNum uint32, uniformRandom *rand.Rand) *Specie
 		}
 		s.Populations[i] = PopulationFactory(nil, 0, uint32(i+1), s.PartsPerPop) 		// genesis population
 		Mdl.GenerateInitialAlleles(s.Populations[i], newRandom)
+		if Mdl.GenerateInitialVariation != nil { Mdl.GenerateInitialVariation(s.Populations[i], newRandom) }
+		if config.Cfg.Population.Initial_inversions != "" { s.Populations[i].GenerateInitialInversions(newRandom) }
+		s.Populations[i].setGenesisFitness()
 	}
 	s.ReportInitial()
 	return s 		// so we can chain calls
@@ -59,8 +62,9 @@ func (s *Species) GetCurrentSize() (size uint32) {
 func (parentS *Species) GetNextGeneration(gen uint32) (childrenS *Species) {
 	random.NextSeed = config.Cfg.Computation.Random_number_seed + 1		// reset the seed to 1 above our initial seed, so when we call RandFactory() in Mate() for additional threads it will work like it did before
 	childrenS = SpeciesFactory()
-	for i := range parentS.Populations {
-		childrenS.Populations[i] = PopulationFactory(parentS.Populations[i], gen, uint32(i+1), parentS.PartsPerPop)	// this creates the PopulationParts too
+	childrenS.Populations = make([]*Population, len(parentS.Populations))		// demographic events can change the number of tribes from num_tribes
+	for i, p := range parentS.Populations {
+		childrenS.Populations[i] = PopulationFactory(p, gen, p.TribeNum, parentS.PartsPerPop)	// this creates the PopulationParts too
 	}
 	return
 }
@@ -104,9 +108,15 @@ func (s *Species) AllPopsDone() bool {
 }
 
 // Go thru all pops and mark as done any that have gone extinct or reached its pop max
-func (s *Species) MarkDonePops() {
+func (s *Species) MarkDonePops(genNum uint32) {
 	for _, p := range s.Populations {
-		if !p.Done && p.IsDone(true) { p.Done = true }
+		if !p.Done && p.IsDone(true) {
+			p.Done = true
+			if Mdl.RecolonizationWeight != nil && p.isExtinct() {
+				p.ExtinctGen = genNum
+				log.Printf("Tribe %d went extinct in generation %d. It will be recolonized in generation %d.", p.TribeNum, genNum, genNum + config.Cfg.Tribes.Recolonization_delay)
+			}
+		}
 	}
 }
 
@@ -116,7 +126,7 @@ func (s *Species) ReportInitial() {
 		p.ReportInitial()
 	}
 
-	if config.Cfg.Tribes.Num_tribes > 1 {
+	if config.MultipleTribes() {
 		// Also initialize the summary/average files for the whole species
 		if histWriter0 := config.FMgr.GetFile(config.HISTORY_FILENAME, 0); histWriter0 != nil {
 			// Write header for this file
@@ -127,6 +137,58 @@ func (s *Species) ReportInitial() {
 			// Write header for this file
 			fmt.Fprintln(fitWriter0, "# Generation  Pop-size  Avg Offspring  Avg-fitness  Min-fitness  Max-fitness  Total Mutns  Mean Mutns  Noise")
 		}
+
+		if invWriter0 := config.FMgr.GetFile(config.INVERSIONS_FILENAME, 0); invWriter0 != nil {
+			writeInversionsHeader(invWriter0)
+		}
+
+		if deathsWriter0 := config.FMgr.GetFile(config.DEATHS_FILENAME, 0); deathsWriter0 != nil {
+			writeDeathsHeader(deathsWriter0)
+		}
+
+		if envWriter0 := config.FMgr.GetFile(config.ENVIRONMENT_FILENAME, 0); envWriter0 != nil {
+			writeEnvironmentHeader(envWriter0)
+		}
+
+		if orgWriter0 := config.FMgr.GetFile(config.ORGANELLE_FILENAME, 0); orgWriter0 != nil {
+			writeOrganelleHeader(orgWriter0)
+		}
+
+		if oriWriter0 := config.FMgr.GetFile(config.MUTATION_ORIGIN_FILENAME, 0); oriWriter0 != nil {
+			writeMutationOriginHeader(oriWriter0)
+		}
+
+		if impWriter0 := config.FMgr.GetFile(config.IMPRINTING_FILENAME, 0); impWriter0 != nil {
+			writeImprintingHeader(impWriter0)
+		}
+
+		if mtrWriter0 := config.FMgr.GetFile(config.MUTATOR_FILENAME, 0); mtrWriter0 != nil {
+			writeMutatorHeader(mtrWriter0)
+		}
+
+		if locWriter0 := config.FMgr.GetFile(config.LOCAL_ADAPTATION_FILENAME, 0); locWriter0 != nil {
+			writeLocalAdaptationHeader(locWriter0)
+		}
+
+		if catWriter0 := config.FMgr.GetFile(config.CATASTROPHE_FILENAME, 0); catWriter0 != nil {
+			writeCatastropheHeader(catWriter0)
+		}
+
+		if nmcWriter0 := config.FMgr.GetFile(config.NEW_MUTATION_COUNTS_FILENAME, 0); nmcWriter0 != nil {
+			writeNewMutationCountsHeader(nmcWriter0)
+		}
+
+		if pedWriter0 := config.FMgr.GetFile(config.PEDIGREE_FILENAME, 0); pedWriter0 != nil {
+			writePedigreeHeader(pedWriter0)
+		}
+
+		if inbWriter0 := config.FMgr.GetFile(config.INBREEDING_FILENAME, 0); inbWriter0 != nil {
+			writeInbreedingHeader(inbWriter0)
+		}
+
+		if fstWriter0 := config.FMgr.GetFile(config.FST_FILENAME, 0); fstWriter0 != nil {
+			writeFstHeader(fstWriter0)
+		}
 	}
 }
 // GetFitnessStats returns the average of all the individuals fitness levels across the pops, as well as the min and max, and total and mean mutations.
@@ -182,7 +244,7 @@ func (s *Species) ReportEachGen(genNum uint32, lastGen bool, totalInterimTime, g
 	}
 
 	// Report the overall species stats
-	if config.Cfg.Tribes.Num_tribes > 1 {
+	if config.MultipleTribes() {
 		perGenMinimalVerboseLevel := uint32(1) // level at which we will print only the info that is very quick to gather
 		finalVerboseLevel := uint32(1)         // level at which we will print species summary info at the end of the run
 		if config.IsVerbose(perGenMinimalVerboseLevel) || (lastGen && config.IsVerbose(finalVerboseLevel)) {
@@ -212,6 +274,132 @@ func (s *Species) ReportEachGen(genNum uint32, lastGen bool, totalInterimTime, g
 				//todo: put summary stats in comments at the end of the file?
 			}
 		}
+
+		if invWriter := config.FMgr.GetFile(config.INVERSIONS_FILENAME, 0); invWriter != nil {
+			// The frequency of each inversion across the whole species
+			config.Verbose(5, "Writing to file %v", config.INVERSIONS_FILENAME)
+			stats := make(map[uint64]*inversionStats)
+			var numChromoSets uint32
+			for _, p := range s.Populations {
+				if !p.Done { numChromoSets += p.gatherInversionStats(stats) }
+			}
+			writeInversionStats(invWriter, genNum, stats, numChromoSets)
+		}
+
+		if deathsWriter := config.FMgr.GetFile(config.DEATHS_FILENAME, 0); deathsWriter != nil {
+			// The total deaths and sterile individuals across the whole species
+			config.Verbose(5, "Writing to file %v", config.DEATHS_FILENAME)
+			var lethal, fitness, selection, sterile uint32
+			for _, p := range s.Populations {
+				if p.Done { continue }
+				lethal += p.NumLethalDeaths
+				fitness += p.NumFitnessDeaths
+				selection += p.NumSelectionDeaths
+				sterile += p.NumSterile
+			}
+			// If you change this line, you must also change the header in writeDeathsHeader()
+			fmt.Fprintf(deathsWriter, "%d  %d  %d  %d  %d\n", genNum, lethal, fitness, selection, sterile)
+		}
+
+		if envWriter := config.FMgr.GetFile(config.ENVIRONMENT_FILENAME, 0); envWriter != nil {
+			// The trait stats across the whole species. All of the tribes have the same optimum, unless it has a gradient across the lattice.
+			config.Verbose(5, "Writing to file %v", config.ENVIRONMENT_FILENAME)
+			stats := newEnvironmentStats()
+			extinct := true
+			for _, p := range s.Populations {
+				if p.Done { continue }
+				p.gatherEnvironmentStats(stats)
+				if !p.isExtinct() { extinct = false }
+			}
+			optimum := Mdl.CalcOptimum(genNum)
+			if Mdl.Lattice != nil && Mdl.Lattice.optimumGradient != 0.0 { optimum = meanOptimum(s.Populations) }
+			writeEnvironmentStats(envWriter, genNum, optimum, stats, extinct)
+		}
+
+		if orgWriter := config.FMgr.GetFile(config.ORGANELLE_FILENAME, 0); orgWriter != nil {
+			config.Verbose(5, "Writing to file %v", config.ORGANELLE_FILENAME)
+			stats := &organelleStats{}
+			for _, p := range s.Populations {
+				if p.Done { continue }
+				p.gatherOrganelleStats(stats)
+			}
+			writeOrganelleStats(orgWriter, genNum, stats)
+		}
+
+		if oriWriter := config.FMgr.GetFile(config.MUTATION_ORIGIN_FILENAME, 0); oriWriter != nil {
+			config.Verbose(5, "Writing to file %v", config.MUTATION_ORIGIN_FILENAME)
+			stats := &mutationOriginStats{}
+			for _, p := range s.Populations {
+				if p.Done { continue }
+				p.gatherMutationOriginStats(stats)
+			}
+			writeMutationOriginStats(oriWriter, genNum, stats)
+		}
+
+		if impWriter := config.FMgr.GetFile(config.IMPRINTING_FILENAME, 0); impWriter != nil {
+			config.Verbose(5, "Writing to file %v", config.IMPRINTING_FILENAME)
+			stats := &imprintingStats{}
+			for _, p := range s.Populations {
+				if p.Done { continue }
+				p.gatherImprintingStats(stats)
+			}
+			writeImprintingStats(impWriter, genNum, stats)
+		}
+
+		if mtrWriter := config.FMgr.GetFile(config.MUTATOR_FILENAME, 0); mtrWriter != nil {
+			config.Verbose(5, "Writing to file %v", config.MUTATOR_FILENAME)
+			stats := newMutatorStats()
+			for _, p := range s.Populations {
+				if p.Done { continue }
+				p.gatherMutatorStats(stats)
+			}
+			writeMutatorStats(mtrWriter, genNum, stats)
+		}
+
+		if locWriter := config.FMgr.GetFile(config.LOCAL_ADAPTATION_FILENAME, 0); locWriter != nil {
+			config.Verbose(5, "Writing to file %v", config.LOCAL_ADAPTATION_FILENAME)
+			stats := &localAdaptationStats{}
+			for _, p := range s.Populations {
+				if p.Done { continue }
+				p.gatherLocalAdaptationStats(stats)
+			}
+			writeLocalAdaptationStats(locWriter, genNum, stats)
+		}
+
+		if nmcWriter := config.FMgr.GetFile(config.NEW_MUTATION_COUNTS_FILENAME, 0); nmcWriter != nil {
+			config.Verbose(5, "Writing to file %v", config.NEW_MUTATION_COUNTS_FILENAME)
+			stats := &newMutationCountStats{}
+			for _, p := range s.Populations {
+				if p.Done { continue }
+				p.gatherNewMutationCountStats(stats)
+			}
+			writeNewMutationCountStats(nmcWriter, genNum, stats)
+		}
+
+		if pedWriter := config.FMgr.GetFile(config.PEDIGREE_FILENAME, 0); pedWriter != nil && isPedigreeGen(genNum) {
+			// The ids are unique across the tribes, so the combined pedigree is just all of the tribes' individuals
+			config.Verbose(5, "Writing to file %v", config.PEDIGREE_FILENAME)
+			for _, p := range s.Populations {
+				if p.Done { continue }
+				p.writePedigree(pedWriter, genNum)
+			}
+		}
+
+		if inbWriter := config.FMgr.GetFile(config.INBREEDING_FILENAME, 0); inbWriter != nil {
+			config.Verbose(5, "Writing to file %v", config.INBREEDING_FILENAME)
+			stats := &inbreedingStats{}
+			for _, p := range s.Populations {
+				if p.Done { continue }
+				p.gatherInbreedingStats(stats)
+			}
+			writeInbreedingStats(inbWriter, genNum, stats)
+		}
+
+		if config.FMgr.IsFile(config.FST_FILENAME) && (lastGen || genNum % config.Cfg.Computation.Fst_gens == 0) {
+			// The fst between each pair of tribes. This also writes the tribes' fst files, because each needs the allele frequencies of all the tribes.
+			config.Verbose(5, "Writing to file %v", config.FST_FILENAME)
+			s.reportFst(genNum)
+		}
 	}
 
 	// Count and output the alleles for each pop
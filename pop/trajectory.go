@@ -0,0 +1,113 @@
+package pop
+
+import (
+	"bufio"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type TrajectoryInterpolationType string
+
+const (
+	LINEAR_TRAJECTORY TrajectoryInterpolationType = "linear"
+	STEP_TRAJECTORY   TrajectoryInterpolationType = "step"
+)
+
+// trajectoryPoint is 1 line of the pop_size_trajectory file
+type trajectoryPoint struct {
+	gen uint32
+	size uint32		// the target size of every tribe without its own size on this line
+	sizes []uint32		// the target size of each tribe (indexed by tribeNum-1)
+}
+
+// tribeSize returns the target size of the tribe at this point. Tribes created during the run by demographic events use the common size.
+func (pt trajectoryPoint) tribeSize(tribeNum uint32) uint32 {
+	if int(tribeNum) > len(pt.sizes) { return pt.size }
+	return pt.sizes[tribeNum-1]
+}
+
+// PopSizeTrajectory is the parsed pop_size_trajectory file that pop_growth_model==trajectory follows
+type PopSizeTrajectory struct {
+	points []trajectoryPoint		// in increasing order of generation
+	linear bool		// true to interpolate linearly between points, false to hold each size until the next point
+}
+
+
+// ReadPopSizeTrajectory reads the pop_size_trajectory file. Each non-comment line is:
+//   generation, target-size [, tribe-1-size, tribe-2-size, ...]
+// The target size applies to every tribe, unless the line has a size for each tribe. An optional header line is skipped.
+func ReadPopSizeTrajectory(fileName string, interpolation string, numTribes uint32) *PopSizeTrajectory {
+	t := &PopSizeTrajectory{}
+	switch TrajectoryInterpolationType(strings.ToLower(interpolation)) {
+	case LINEAR_TRAJECTORY:
+		t.linear = true
+	case STEP_TRAJECTORY:
+		t.linear = false
+	default:
+		log.Fatalf("Error: unrecognized value for pop_size_trajectory_interpolation: %v", interpolation)
+	}
+
+	file, err := os.Open(fileName)
+	if err != nil { log.Fatalf("Error opening pop_size_trajectory %v: %v", fileName, err) }
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") { continue }
+		fields := strings.Split(line, ",")
+		for i := range fields { fields[i] = strings.TrimSpace(fields[i]) }
+		gen, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			if len(t.points) == 0 { continue }		// the header line
+			log.Fatalf("Error parsing the generation on line %d of %v: %v", lineNum, fileName, err)
+		}
+		if len(fields) != 2 && len(fields) != 2 + int(numTribes) { log.Fatalf("Error: line %d of %v must have 2 fields, or 2 + num_tribes fields", lineNum, fileName) }
+		if len(t.points) > 0 && uint32(gen) <= t.points[len(t.points)-1].gen { log.Fatalf("Error: the generations in %v must be in increasing order (line %d)", fileName, lineNum) }
+
+		nums := make([]uint32, len(fields)-1)
+		for i := range nums {
+			n, err := strconv.ParseUint(fields[i+1], 10, 32)
+			if err != nil { log.Fatalf("Error parsing line %d of %v: %v", lineNum, fileName, err) }
+			if n == 0 { log.Fatalf("Error: the sizes on line %d of %v must be > 0", lineNum, fileName) }
+			nums[i] = uint32(n)
+		}
+		point := trajectoryPoint{gen: uint32(gen), size: nums[0], sizes: make([]uint32, numTribes)}
+		for i := range point.sizes {
+			if len(nums) > 1 {
+				point.sizes[i] = nums[i+1]
+			} else {
+				point.sizes[i] = nums[0]
+			}
+		}
+		t.points = append(t.points, point)
+	}
+	if err := scanner.Err(); err != nil { log.Fatalf("Error reading %v: %v", fileName, err) }
+	if len(t.points) == 0 { log.Fatalf("Error: %v does not contain any generation,size lines", fileName) }
+	return t
+}
+
+
+// TargetSize returns the target size of the tribe in generation genNum. Before the 1st point the 1st size is used, and after the last point the last size.
+func (t *PopSizeTrajectory) TargetSize(genNum, tribeNum uint32) uint32 {
+	if genNum <= t.points[0].gen { return t.points[0].tribeSize(tribeNum) }
+	for p := 1; p < len(t.points); p++ {
+		next := t.points[p]
+		if genNum >= next.gen { continue }
+		prev := t.points[p-1]
+		if !t.linear { return prev.tribeSize(tribeNum) }
+		frac := float64(genNum - prev.gen) / float64(next.gen - prev.gen)
+		prevSize, nextSize := float64(prev.tribeSize(tribeNum)), float64(next.tribeSize(tribeNum))
+		return uint32(math.Round(prevSize + frac * (nextSize - prevSize)))
+	}
+	return t.points[len(t.points)-1].tribeSize(tribeNum)
+}
+
+
+// TrajectoryPopulationGrowth returns the size the pop_size_trajectory file gives for this generation, regardless of the previous size
+func TrajectoryPopulationGrowth(prevPop *Population, genNum uint32) uint32 {
+	return Mdl.PopSizeTrajectory.TargetSize(genNum, prevPop.TribeNum)
+}
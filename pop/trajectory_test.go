@@ -0,0 +1,98 @@
+package pop
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTrajectoryFile writes the contents to a pop_size_trajectory file in a temp dir and returns its path
+func writeTrajectoryFile(t *testing.T, contents string) string {
+	fileName := filepath.Join(t.TempDir(), "trajectory.csv")
+	if err := os.WriteFile(fileName, []byte(contents), 0644); err != nil { t.Fatalf("error writing %v: %v", fileName, err) }
+	return fileName
+}
+
+// trajectoryCase is the expected target size of a tribe in a generation
+type trajectoryCase struct {
+	gen, tribeNum, want uint32
+}
+
+// checkTargetSizes compares the target size the trajectory gives for each case to the expected one
+func checkTargetSizes(t *testing.T, traj *PopSizeTrajectory, tests []trajectoryCase) {
+	for _, tc := range tests {
+		if got := traj.TargetSize(tc.gen, tc.tribeNum); got != tc.want { t.Errorf("gen %d, tribe %d: expected target size %d, got %d", tc.gen, tc.tribeNum, tc.want, got) }
+	}
+}
+
+// Checks that step interpolation holds each size until the next point, and uses the 1st/last size before/after the points
+func TestTrajectoryStep(t *testing.T) {
+	fileName := writeTrajectoryFile(t, "generation, size\n10, 100\n20, 200\n30, 50\n")
+	traj := ReadPopSizeTrajectory(fileName, "step", 1)
+	checkTargetSizes(t, traj, []trajectoryCase{
+		{1, 1, 100},		// before the 1st point
+		{10, 1, 100},
+		{15, 1, 100},		// between 2 points
+		{19, 1, 100},
+		{20, 1, 200},
+		{29, 1, 200},
+		{30, 1, 50},
+		{40, 1, 50},		// after the last point
+	})
+}
+
+// Checks that linear interpolation rounds to the nearest size between 2 points, and uses the 1st/last size before/after the points
+func TestTrajectoryLinear(t *testing.T) {
+	fileName := writeTrajectoryFile(t, "# a comment\n10, 100\n\n20, 200\n30, 50\n")
+	traj := ReadPopSizeTrajectory(fileName, "Linear", 1)
+	checkTargetSizes(t, traj, []trajectoryCase{
+		{1, 1, 100},		// before the 1st point
+		{10, 1, 100},
+		{15, 1, 150},		// between 2 points
+		{13, 1, 130},
+		{20, 1, 200},
+		{21, 1, 185},		// decreasing
+		{25, 1, 125},
+		{30, 1, 50},
+		{40, 1, 50},		// after the last point
+	})
+}
+
+// Checks that lines with a size for each tribe give each tribe its own trajectory, and that tribes created during the run use the common size
+func TestTrajectoryTribes(t *testing.T) {
+	fileName := writeTrajectoryFile(t, "gen, size, tribe-1, tribe-2\n10, 100, 50, 150\n20, 200, 70, 250\n")
+	linear := ReadPopSizeTrajectory(fileName, "linear", 2)
+	checkTargetSizes(t, linear, []trajectoryCase{
+		{5, 1, 50},		// before the 1st point
+		{5, 2, 150},
+		{5, 3, 100},
+		{15, 1, 60},		// between 2 points
+		{15, 2, 200},
+		{15, 3, 150},
+		{25, 1, 70},		// after the last point
+		{25, 2, 250},
+		{25, 3, 200},
+	})
+
+	step := ReadPopSizeTrajectory(fileName, "step", 2)
+	checkTargetSizes(t, step, []trajectoryCase{
+		{5, 2, 150},
+		{15, 1, 50},
+		{15, 2, 150},
+		{15, 3, 100},
+		{20, 2, 250},
+		{25, 3, 200},
+	})
+}
+
+// Checks that a line with only the common size sets every tribe to it
+func TestTrajectoryCommonSize(t *testing.T) {
+	fileName := writeTrajectoryFile(t, "10, 100\n20, 200, 70, 250\n")
+	traj := ReadPopSizeTrajectory(fileName, "linear", 2)
+	checkTargetSizes(t, traj, []trajectoryCase{
+		{10, 1, 100},
+		{10, 2, 100},
+		{15, 1, 85},
+		{15, 2, 175},
+	})
+}
@@ -0,0 +1,288 @@
+package pop
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/dna"
+	"github.com/genetic-algorithms/mendel-go/utils"
+)
+
+// The tree sequence (ancestral recombination graph) records the genealogy of the chromosomes of a population: a node for each haploid
+// genome (chromosome set) of each individual that survives selection, an edge for each range of LBs a genome inherited from a parent genome,
+// and the new mutations on each genome. Positions are global LB indexes: chromosome c, LB lb is at c*LBsPerChromosome+lb. The tables are
+// periodically simplified to only what is ancestral to the current generation, so memory stays bounded.
+
+type tsEdge struct {
+	left, right uint32
+	parent, child int32
+}
+
+type tsMutation struct {
+	position uint32		// the global LB index
+	id uint64
+	mType dna.MutationType
+	node int32
+}
+
+// ancestrySegment is a range of positions of a genome whose ancestry is carried by node in the simplified output
+type ancestrySegment struct {
+	left, right uint32
+	node int32
+}
+
+type treeSequence struct {
+	genNum uint32		// the current generation
+	nodeGens []uint32		// the generation each node was born in
+	edges []tsEdge
+	mutations []tsMutation
+	numSamples int		// after simplify(), nodes 0 - numSamples-1 are the current generation
+}
+
+
+// addNode adds a genome born in the current generation and returns its node id
+func (ts *treeSequence) addNode() int32 {
+	ts.nodeGens = append(ts.nodeGens, ts.genNum)
+	return int32(len(ts.nodeGens) - 1)
+}
+
+// addEdge adds an edge, extending the previous one if it is a contiguous range from the same parent to the same child
+func (ts *treeSequence) addEdge(left, right uint32, parent, child int32) {
+	if last := len(ts.edges) - 1; last >= 0 && ts.edges[last].parent == parent && ts.edges[last].child == child && ts.edges[last].right == left {
+		ts.edges[last].right = right
+		return
+	}
+	ts.edges = append(ts.edges, tsEdge{left: left, right: right, parent: parent, child: child})
+}
+
+
+// forEachGenome calls f with the chromosomes of each chromosome set of each individual of this population, in the order the sample nodes are numbered
+func (p *Population) forEachGenome(f func(chromosomes []dna.Chromosome)) {
+	n := int(config.Cfg.Population.Haploid_chromosome_number)
+	for _, indRef := range p.IndivRefs {
+		for _, chromosomes := range [][]dna.Chromosome{indRef.Indiv.ChromosomesFromDad, indRef.Indiv.ChromosomesFromMom} {
+			for set := 0; set*n < len(chromosomes); set++ { f(chromosomes[set*n : (set+1)*n]) }
+		}
+	}
+}
+
+
+// initGenesisTreeSequence creates the tree sequence with a node for each genome of the genesis population. They have no recorded ancestry.
+func (p *Population) initGenesisTreeSequence() {
+	p.TreeSeq = &treeSequence{}
+	p.forEachGenome(func(chromosomes []dna.Chromosome) {
+		node := p.TreeSeq.addNode()
+		for c := range chromosomes { chromosomes[c].Node = node }
+	})
+	p.TreeSeq.numSamples = len(p.TreeSeq.nodeGens)
+}
+
+
+// recordTreeSequence is called after selection to add a node for each genome of the survivors, with the edges to their parent genomes
+// and their new mutations. Every tree_sequence_simplify_interval generations it also simplifies the tree sequence.
+func (p *Population) recordTreeSequence() {
+	ts := p.TreeSeq
+	p.forEachGenome(func(chromosomes []dna.Chromosome) {
+		node := ts.addNode()
+		for c := range chromosomes {
+			chr := &chromosomes[c]
+			offset := uint32(c) * p.LBsPerChromosome
+			for _, seg := range chr.Segments { ts.addEdge(offset+seg.Left, offset+seg.Right, seg.Parent, node) }
+			for _, m := range chr.NewMutations { ts.mutations = append(ts.mutations, tsMutation{position: offset+m.LB, id: m.Id, mType: m.Type, node: node}) }
+			chr.Node = node
+			chr.ClearTreeSequenceRecords()
+		}
+	})
+	if Mdl.InheritOrganelle != nil {
+		// The organelle genome is not part of the tree sequence, so just release what Copy() and AppendMutation() recorded for it
+		for _, indRef := range p.IndivRefs { indRef.Indiv.Organelle.ClearTreeSequenceRecords() }
+	}
+	if ts.genNum % config.Cfg.Computation.Tree_sequence_simplify_interval == 0 { p.simplifyTreeSequence() }
+}
+
+
+// simplifyTreeSequence reduces the tree sequence to the genealogy of the genomes of the current population, and renumbers the nodes of their chromosomes.
+func (p *Population) simplifyTreeSequence() {
+	samples := make([]int32, 0, 2*len(p.IndivRefs))
+	p.forEachGenome(func(chromosomes []dna.Chromosome) { samples = append(samples, chromosomes[0].Node) })
+	nodeMap := p.TreeSeq.simplify(samples, config.Cfg.Population.Num_linkage_subunits)
+	p.forEachGenome(func(chromosomes []dna.Chromosome) {
+		for c := range chromosomes { chromosomes[c].Node = nodeMap[chromosomes[c].Node] }
+	})
+}
+
+
+// simplify replaces the tables with the minimal ones that describe the genealogy of the samples over positions [0,seqLength), using the
+// algorithm of Kelleher et al. (2018). The samples become nodes 0 - len(samples)-1, the other nodes that are kept are those where lineages
+// of the samples coalesce, and mutations that are not ancestral to any sample are dropped. The samples must be from the current
+// generation (so they are not parents of any other node). Returns the new id of each old node (-1 if it was dropped).
+func (ts *treeSequence) simplify(samples []int32, seqLength uint32) []int32 {
+	nodeMap := make([]int32, len(ts.nodeGens))
+	for i := range nodeMap { nodeMap[i] = -1 }
+	ancestry := make([][]ancestrySegment, len(ts.nodeGens))
+	newNodeGens := make([]uint32, 0, len(samples))
+	for _, s := range samples {
+		nodeMap[s] = int32(len(newNodeGens))
+		newNodeGens = append(newNodeGens, ts.nodeGens[s])
+		ancestry[s] = []ancestrySegment{{left: 0, right: seqLength, node: nodeMap[s]}}
+	}
+
+	// Process the parents from the most recent generation back, so the ancestry of all of a parent's children is known when we get to it
+	sort.SliceStable(ts.edges, func(i, j int) bool {
+		gi, gj := ts.nodeGens[ts.edges[i].parent], ts.nodeGens[ts.edges[j].parent]
+		if gi != gj { return gi > gj }
+		return ts.edges[i].parent < ts.edges[j].parent
+	})
+	var newEdges []tsEdge
+	for start := 0; start < len(ts.edges); {
+		u := ts.edges[start].parent
+		end := start
+		var overlaps []ancestrySegment
+		for ; end < len(ts.edges) && ts.edges[end].parent == u; end++ {
+			e := ts.edges[end]
+			for _, x := range ancestry[e.child] {
+				if x.right > e.left && e.right > x.left { overlaps = append(overlaps, ancestrySegment{left: utils.MaxUint32(x.left, e.left), right: utils.MinUint32(x.right, e.right), node: x.node}) }
+			}
+		}
+		start = end
+		if len(overlaps) == 0 { continue }
+
+		// Sweep across the overlapping ancestry of the children. Where only 1 child's ancestry covers a range, it passes thru u unchanged. Where
+		// more than 1 do, they coalesce in u, so u is kept as a node with an edge to each of them.
+		sort.SliceStable(overlaps, func(i, j int) bool { return overlaps[i].left < overlaps[j].left })
+		v := int32(-1)
+		var vEdges []tsEdge
+		var active []ancestrySegment
+		left := uint32(0)
+		for i := 0; i < len(overlaps) || len(active) > 0; {
+			if len(active) == 0 { left = overlaps[i].left }
+			for ; i < len(overlaps) && overlaps[i].left == left; i++ { active = append(active, overlaps[i]) }
+			right := active[0].right
+			for _, x := range active { right = utils.MinUint32(right, x.right) }
+			if i < len(overlaps) { right = utils.MinUint32(right, overlaps[i].left) }
+			if len(active) == 1 {
+				ancestry[u] = appendAncestry(ancestry[u], left, right, active[0].node)
+			} else {
+				if v < 0 {
+					v = int32(len(newNodeGens))
+					newNodeGens = append(newNodeGens, ts.nodeGens[u])
+					nodeMap[u] = v
+				}
+				ancestry[u] = appendAncestry(ancestry[u], left, right, v)
+				for _, x := range active { vEdges = append(vEdges, tsEdge{left: left, right: right, parent: v, child: x.node}) }
+			}
+			stillActive := active[:0]
+			for _, x := range active {
+				if x.right > right { stillActive = append(stillActive, x) }
+			}
+			active = stillActive
+			left = right
+		}
+
+		// Combine the contiguous edges to each child
+		sort.SliceStable(vEdges, func(i, j int) bool {
+			if vEdges[i].child != vEdges[j].child { return vEdges[i].child < vEdges[j].child }
+			return vEdges[i].left < vEdges[j].left
+		})
+		for _, e := range vEdges {
+			if last := len(newEdges) - 1; last >= 0 && newEdges[last].parent == e.parent && newEdges[last].child == e.child && newEdges[last].right == e.left {
+				newEdges[last].right = e.right
+			} else {
+				newEdges = append(newEdges, e)
+			}
+		}
+	}
+
+	// A mutation is carried by the node whose ancestry covers its position in the genome it arose on
+	newMutations := make([]tsMutation, 0, len(ts.mutations))
+	for _, m := range ts.mutations {
+		for _, x := range ancestry[m.node] {
+			if m.position >= x.left && m.position < x.right {
+				m.node = x.node
+				newMutations = append(newMutations, m)
+				break
+			}
+		}
+	}
+
+	ts.nodeGens, ts.edges, ts.mutations, ts.numSamples = newNodeGens, newEdges, newMutations, len(samples)
+	return nodeMap
+}
+
+// appendAncestry adds the range [left,right) carried by node to the ancestry segments of a node, extending the last one if possible
+func appendAncestry(segs []ancestrySegment, left, right uint32, node int32) []ancestrySegment {
+	if last := len(segs) - 1; last >= 0 && segs[last].node == node && segs[last].right == left {
+		segs[last].right = right
+		return segs
+	}
+	return append(segs, ancestrySegment{left: left, right: right, node: node})
+}
+
+
+// sitePosition returns the position of a mutation's site: its LB plus a fraction derived from its unique id, so different mutations in the same LB
+// are at different sites (infinite sites). The fraction only depends on the id, so it is reproducible.
+func sitePosition(m tsMutation) float64 {
+	const scale = 100000000
+	return float64(m.position) + float64((m.id * 0x9E3779B97F4A7C15) >> 11 % scale) / scale
+}
+
+
+// ReportTreeSequence simplifies the tree sequence to the genealogy of the current population, and writes it to the tree-sequence dir as the
+// nodes.txt, edges.txt, sites.txt, and mutations.txt tables that tskit.load_text() reads. Times are in generations before genNum.
+func (p *Population) ReportTreeSequence(genNum uint32) {
+	if !config.FMgr.IsDir(config.TREE_SEQUENCE_DIRECTORY) || p.TreeSeq == nil { return }
+	p.simplifyTreeSequence()
+	ts := p.TreeSeq
+	config.Verbose(1, "Writing the tree sequence: %d nodes, %d edges, %d mutations", len(ts.nodeGens), len(ts.edges), len(ts.mutations))
+
+	writeTable := func(fileName string, write func(w *os.File)) {
+		if w := config.FMgr.GetDirFile(config.TREE_SEQUENCE_DIRECTORY, fileName, p.TribeNum); w != nil {
+			write(w)
+			config.FMgr.CloseDirFile(config.TREE_SEQUENCE_DIRECTORY, fileName, p.TribeNum)
+		}
+	}
+
+	writeTable("nodes.txt", func(w *os.File) {
+		if _, err := fmt.Fprintln(w, "is_sample\ttime"); err != nil { log.Fatalf("error writing nodes.txt: %v", err) }
+		for i, gen := range ts.nodeGens {
+			isSample := 0
+			if i < ts.numSamples { isSample = 1 }
+			fmt.Fprintf(w, "%d\t%d\n", isSample, genNum-gen)
+		}
+	})
+
+	// tskit requires the edges to be ordered by the time of their parent, most recent first
+	sort.SliceStable(ts.edges, func(i, j int) bool {
+		ei, ej := ts.edges[i], ts.edges[j]
+		if ts.nodeGens[ei.parent] != ts.nodeGens[ej.parent] { return ts.nodeGens[ei.parent] > ts.nodeGens[ej.parent] }
+		if ei.parent != ej.parent { return ei.parent < ej.parent }
+		if ei.child != ej.child { return ei.child < ej.child }
+		return ei.left < ej.left
+	})
+	writeTable("edges.txt", func(w *os.File) {
+		if _, err := fmt.Fprintln(w, "left\tright\tparent\tchild"); err != nil { log.Fatalf("error writing edges.txt: %v", err) }
+		for _, e := range ts.edges { fmt.Fprintf(w, "%d\t%d\t%d\t%d\n", e.left, e.right, e.parent, e.child) }
+	})
+
+	// Each mutation gets its own site, and the sites have to be in order of position
+	positions := make([]float64, len(ts.mutations))
+	for i := range ts.mutations { positions[i] = sitePosition(ts.mutations[i]) }
+	order := make([]int, len(ts.mutations))
+	for i := range order { order[i] = i }
+	sort.SliceStable(order, func(i, j int) bool { return positions[order[i]] < positions[order[j]] })
+	for k := 1; k < len(order); k++ {
+		if positions[order[k]] <= positions[order[k-1]] { positions[order[k]] = positions[order[k-1]] + 1e-8 }		// site positions must be unique
+	}
+	writeTable("sites.txt", func(w *os.File) {
+		if _, err := fmt.Fprintln(w, "position\tancestral_state"); err != nil { log.Fatalf("error writing sites.txt: %v", err) }
+		for _, i := range order { fmt.Fprintf(w, "%s\t0\n", strconv.FormatFloat(positions[i], 'f', -1, 64)) }		// the shortest representation that is unique for each position
+	})
+	writeTable("mutations.txt", func(w *os.File) {
+		if _, err := fmt.Fprintln(w, "site\tnode\tderived_state"); err != nil { log.Fatalf("error writing mutations.txt: %v", err) }
+		for site, i := range order { fmt.Fprintf(w, "%d\t%d\t%s\n", site, ts.mutations[i].node, ts.mutations[i].mType.DerivedState()) }
+	})
+}
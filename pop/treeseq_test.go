@@ -0,0 +1,66 @@
+package pop
+
+import (
+	"reflect"
+	"testing"
+)
+
+// buildTreeSequence returns a tree sequence of 2 genesis genomes (nodes 0 and 1) and 2 genomes in generation 1 (nodes 2 and 3). Over the 10 LBs,
+// node 2 inherited everything from node 0, and node 3 inherited [0,5) from node 0 and [5,10) from node 1.
+func buildTreeSequence() *treeSequence {
+	ts := &treeSequence{}
+	ts.addNode()
+	ts.addNode()
+	ts.genNum = 1
+	ts.addNode()
+	ts.addNode()
+	ts.addEdge(0, 5, 0, 2)
+	ts.addEdge(5, 10, 0, 2)		// extends the previous edge
+	ts.addEdge(0, 5, 0, 3)
+	ts.addEdge(5, 10, 1, 3)
+	ts.mutations = []tsMutation{
+		{position: 2, id: 1, node: 0},		// inherited by both samples
+		{position: 7, id: 2, node: 0},		// inherited only by node 2
+		{position: 3, id: 3, node: 1},		// not inherited by any sample
+		{position: 8, id: 4, node: 1},		// inherited only by node 3
+	}
+	return ts
+}
+
+// Checks simplify() on a hand-built genealogy: the lineages of the 2 samples only coalesce in node 0 over [0,5)
+func TestSimplify(t *testing.T) {
+	ts := buildTreeSequence()
+	if len(ts.edges) != 3 { t.Fatalf("expected the contiguous edges to be combined into 3, got %v", ts.edges) }
+
+	nodeMap := ts.simplify([]int32{2, 3}, 10)
+	if want := []int32{2, -1, 0, 1}; !reflect.DeepEqual(nodeMap, want) { t.Errorf("expected node map %v, got %v", want, nodeMap) }
+	if want := []uint32{1, 1, 0}; !reflect.DeepEqual(ts.nodeGens, want) { t.Errorf("expected node generations %v, got %v", want, ts.nodeGens) }
+	if ts.numSamples != 2 { t.Errorf("expected 2 samples, got %d", ts.numSamples) }
+	if want := []tsEdge{{0, 5, 2, 0}, {0, 5, 2, 1}}; !reflect.DeepEqual(ts.edges, want) { t.Errorf("expected edges %v, got %v", want, ts.edges) }
+	wantMutations := []tsMutation{{position: 2, id: 1, node: 2}, {position: 7, id: 2, node: 0}, {position: 8, id: 4, node: 1}}
+	if !reflect.DeepEqual(ts.mutations, wantMutations) { t.Errorf("expected mutations %v, got %v", wantMutations, ts.mutations) }
+}
+
+// Checks that nodes that only pass ancestry thru (each sample's parent in generation 1) are removed, and simplifying again changes nothing
+func TestSimplifyUnaryNodes(t *testing.T) {
+	ts := buildTreeSequence()
+	ts.genNum = 2
+	ts.addNode()
+	ts.addNode()
+	ts.addEdge(0, 10, 2, 4)
+	ts.addEdge(0, 10, 3, 5)
+	ts.mutations = append(ts.mutations, tsMutation{position: 6, id: 5, node: 2})
+
+	nodeMap := ts.simplify([]int32{4, 5}, 10)
+	if want := []int32{2, -1, -1, -1, 0, 1}; !reflect.DeepEqual(nodeMap, want) { t.Errorf("expected node map %v, got %v", want, nodeMap) }
+	if want := []uint32{2, 2, 0}; !reflect.DeepEqual(ts.nodeGens, want) { t.Errorf("expected node generations %v, got %v", want, ts.nodeGens) }
+	wantEdges := []tsEdge{{0, 5, 2, 0}, {0, 5, 2, 1}}
+	if !reflect.DeepEqual(ts.edges, wantEdges) { t.Errorf("expected edges %v, got %v", wantEdges, ts.edges) }
+	wantMutations := []tsMutation{{position: 2, id: 1, node: 2}, {position: 7, id: 2, node: 0}, {position: 8, id: 4, node: 1}, {position: 6, id: 5, node: 0}}
+	if !reflect.DeepEqual(ts.mutations, wantMutations) { t.Errorf("expected mutations %v, got %v", wantMutations, ts.mutations) }
+
+	nodeMap = ts.simplify([]int32{0, 1}, 10)
+	if want := []int32{0, 1, 2}; !reflect.DeepEqual(nodeMap, want) { t.Errorf("expected the 2nd simplify to keep every node, got node map %v", nodeMap) }
+	if !reflect.DeepEqual(ts.edges, wantEdges) { t.Errorf("expected the 2nd simplify to keep edges %v, got %v", wantEdges, ts.edges) }
+	if !reflect.DeepEqual(ts.mutations, wantMutations) { t.Errorf("expected the 2nd simplify to keep mutations %v, got %v", wantMutations, ts.mutations) }
+}
@@ -0,0 +1,135 @@
+package pop
+
+import (
+	"math/rand"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/dna"
+	"github.com/genetic-algorithms/mendel-go/random"
+	"github.com/genetic-algorithms/mendel-go/utils"
+)
+
+type InitialVariationModelType string
+
+const (
+	NO_INITIAL_VARIATION         InitialVariationModelType = "none"
+	COALESCENT_INITIAL_VARIATION InitialVariationModelType = "coalescent"
+)
+
+// Algorithms for giving the genesis population standing variation
+type GenerateInitialVariationType func(p *Population, uniformRandom *rand.Rand)
+
+
+// coalescentTree is the genealogy of a sample of genomes: nodes 0 - n-1 are the genomes, and each node after that is the common ancestor of its 2 children.
+type coalescentTree struct {
+	times []float64		// the number of generations ago each node lived
+	children [][2]int32		// the children of each internal node (indexed by node-n)
+	n int
+}
+
+// newCoalescentTree simulates the Kingman coalescent of n genomes in a population in which each pair of lineages coalesces with probability 1/numGenomes per generation
+func newCoalescentTree(n int, numGenomes float64, uniformRandom *rand.Rand) *coalescentTree {
+	tree := &coalescentTree{times: make([]float64, n, 2*n-1), children: make([][2]int32, 0, n-1), n: n}
+	lineages := make([]int32, n)
+	for i := range lineages { lineages[i] = int32(i) }
+	t := 0.0
+	for k := n; k > 1; k-- {
+		t += uniformRandom.ExpFloat64() * numGenomes / float64(k*(k-1)/2)
+		i := uniformRandom.Intn(k)
+		j := uniformRandom.Intn(k - 1)
+		if j >= i { j++ }
+		if i == k-1 { i, j = j, i }		// so the last lineage is the one removed
+		tree.children = append(tree.children, [2]int32{lineages[i], lineages[j]})
+		tree.times = append(tree.times, t)
+		lineages[i] = int32(len(tree.times) - 1)
+		lineages[j] = lineages[k-1]
+		lineages = lineages[:k-1]
+	}
+	return tree
+}
+
+// parents returns the parent of each node (-1 for the root)
+func (tree *coalescentTree) parents() []int32 {
+	parents := make([]int32, len(tree.times))
+	parents[len(parents)-1] = -1
+	for i, ch := range tree.children {
+		parents[ch[0]], parents[ch[1]] = int32(tree.n+i), int32(tree.n+i)
+	}
+	return parents
+}
+
+// leaves returns the genomes that descend from node
+func (tree *coalescentTree) leaves(node int32) (leaves []int32) {
+	stack := []int32{node}
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if int(v) < tree.n {
+			leaves = append(leaves, v)
+		} else {
+			stack = append(stack, tree.children[int(v)-tree.n][0], tree.children[int(v)-tree.n][1])
+		}
+	}
+	return
+}
+
+
+// GenerateCoalescentVariation gives the genesis population the neutral and weakly selected variation it would have at mutation-drift equilibrium.
+// For each LB, it simulates the coalescent of all of the genomes (chromosome sets) of the population, places mutations on its branches at the
+// per-LB mutation rate, and gives each mutation to all of the genomes below its branch. Different LBs have independent genealogies. Mutations
+// whose expressed fitness effect is bigger than 1/(ploidy*pop_size), the threshold below which drift dominates selection, are discarded
+// because they would not be segregating at neutral frequencies.
+func GenerateCoalescentVariation(p *Population, uniformRandom *rand.Rand) {
+	var genomes [][]dna.Chromosome
+	var owners []*Individual
+	n := int(config.Cfg.Population.Haploid_chromosome_number)
+	for _, indRef := range p.IndivRefs {
+		for _, chromosomes := range [][]dna.Chromosome{indRef.Indiv.ChromosomesFromDad, indRef.Indiv.ChromosomesFromMom} {
+			for set := 0; set*n < len(chromosomes); set++ {
+				genomes = append(genomes, chromosomes[set*n : (set+1)*n])
+				owners = append(owners, indRef.Indiv)
+			}
+		}
+	}
+	if len(genomes) < 2 { return }
+	numGenomes := float64(config.Cfg.Population.Ploidy) * float64(len(p.IndivRefs))
+	mutnRatePerLB := config.Cfg.Mutations.Mutn_rate / (float64(config.Cfg.Population.Ploidy) * float64(config.Cfg.Population.Num_linkage_subunits))
+	maxEffect := 1.0 / numGenomes
+
+	var numVariants uint32
+	for c := range genomes[0] {
+		for lb := 0; lb < int(p.LBsPerChromosome); lb++ {
+			tree := newCoalescentTree(len(genomes), numGenomes, uniformRandom)
+			parents := tree.parents()
+			for node := range parents {
+				if parents[node] < 0 { continue }
+				numMutns := random.Poisson(uniformRandom, mutnRatePerLB * (tree.times[parents[node]] - tree.times[node]))
+				for m := uint32(0); m < numMutns; m++ {
+					// Note: we can use the global UniqueInt object because this is called before we create go routines.
+					mutn, ok := dna.CreateStandingVariant(utils.GlobalUniqueInt.NextInt(), maxEffect, uniformRandom)
+					if !ok { continue }
+					numVariants++
+					for _, g := range tree.leaves(int32(node)) {
+						genomes[g][c].AppendStandingVariant(lb, mutn)
+						owners[g].countStandingVariant(mutn.Type)
+					}
+				}
+			}
+		}
+	}
+
+	config.Verbose(1, "Gave the genesis population %d segregating variants from the coalescent", numVariants)
+}
+
+// countStandingVariant adds a standing variant of type mType to the mutation counts of this individual
+func (ind *Individual) countStandingVariant(mType dna.MutationType) {
+	switch mType {
+	case dna.DELETERIOUS_DOMINANT, dna.DELETERIOUS_RECESSIVE:
+		ind.NumDeleterious++
+	case dna.NEUTRAL:
+		ind.NumNeutral++
+	case dna.FAVORABLE_DOMINANT, dna.FAVORABLE_RECESSIVE:
+		ind.NumFavorable++
+	}
+	ind.NumMutations++
+}
@@ -0,0 +1,68 @@
+package pop
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/genetic-algorithms/mendel-go/config"
+	"github.com/genetic-algorithms/mendel-go/dna"
+	"github.com/genetic-algorithms/mendel-go/utils"
+)
+
+// Checks that every coalescent tree joins all of the genomes into 1 root, going back in time
+func TestCoalescentTreeShape(t *testing.T) {
+	uniformRandom := rand.New(rand.NewSource(1))
+	for _, n := range []int{2, 3, 10} {
+		tree := newCoalescentTree(n, 20.0, uniformRandom)
+		if len(tree.times) != 2*n-1 { t.Fatalf("n %d: expected %d nodes, got %d", n, 2*n-1, len(tree.times)) }
+		parents := tree.parents()
+		for node, parent := range parents {
+			if parent >= 0 && tree.times[parent] <= tree.times[node] { t.Errorf("n %d: node %d is not older than its child %d", n, parent, node) }
+		}
+		if root := int32(len(parents) - 1); len(tree.leaves(root)) != n { t.Errorf("n %d: expected the root to have %d leaves, got %d", n, n, len(tree.leaves(root))) }
+	}
+}
+
+// Checks that the mean number of segregating sites GenerateCoalescentVariation gives each LB matches Watterson's expectation theta * sum(1/i, i=1..n-1),
+// where theta = 2 * numGenomes * (mutation rate per LB)
+func TestCoalescentSegregatingSites(t *testing.T) {
+	config.Cfg = &config.Config{}
+	config.Cfg.Population.Ploidy = 2
+	config.Cfg.Population.Haploid_chromosome_number = 1
+	config.Cfg.Population.Num_linkage_subunits = 10
+	config.Cfg.Mutations.Mutn_rate = 1.0
+	config.Cfg.Mutations.Fraction_neutral = 1.0
+	config.Cfg.Computation.Track_neutrals = true
+	Mdl = &Models{}
+	utils.GlobalUniqueIntFactory()
+
+	const popSize, numReps = 5, 200
+	numGenomes := float64(config.Cfg.Population.Ploidy * popSize)
+	theta := 2.0 * numGenomes * config.Cfg.Mutations.Mutn_rate / (float64(config.Cfg.Population.Ploidy) * float64(config.Cfg.Population.Num_linkage_subunits))
+	var harmonic float64
+	for i := 1.0; i < numGenomes; i++ { harmonic += 1.0 / i }
+
+	uniformRandom := rand.New(rand.NewSource(1))
+	var numSites int
+	for rep := 0; rep < numReps; rep++ {
+		p := &Population{LBsPerChromosome: config.Cfg.Population.Num_linkage_subunits}
+		part := &PopulationPart{Pop: p}
+		for i := 0; i < popSize; i++ { part.Indivs = append(part.Indivs, IndividualFactory(part, false)) }
+		p.Parts = []*PopulationPart{part}
+		p.makeAndFillIndivRefs()
+		GenerateCoalescentVariation(p, uniformRandom)
+
+		alleles := dna.AlleleCountFactory()
+		for _, indRef := range p.IndivRefs {
+			for _, chromosomes := range [][]dna.Chromosome{indRef.Indiv.ChromosomesFromDad, indRef.Indiv.ChromosomesFromMom} {
+				for i := range chromosomes { chromosomes[i].CountAlleles(alleles) }
+			}
+		}
+		numSites += len(alleles.Neutral)
+	}
+
+	want := theta * harmonic
+	got := float64(numSites) / float64(numReps * int(config.Cfg.Population.Num_linkage_subunits))
+	if math.Abs(got - want) > 0.05 * want { t.Errorf("expected a mean of %v segregating sites per LB, got %v", want, got) }
+}
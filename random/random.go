@@ -79,6 +79,39 @@ func Poisson(uniformRandom *rand.Rand, lambda float64) uint32 {
 }
 
 
+// Gamma returns a random number from the gamma distribution with the specified shape and scale (mean = shape * scale).
+// Uses the algorithm of Marsaglia and Tsang (2000), with the standard boost for shape < 1.
+func Gamma(uniformRandom *rand.Rand, shape, scale float64) float64 {
+	if shape < 1.0 {
+		// Use the fact that if X ~ Gamma(shape+1) and U ~ uniform(0,1), then X * U^(1/shape) ~ Gamma(shape)
+		u := uniformRandom.Float64()
+		return Gamma(uniformRandom, shape + 1.0, scale) * math.Pow(u, 1.0 / shape)
+	}
+
+	d := shape - 1.0 / 3.0
+	c := 1.0 / math.Sqrt(9.0 * d)
+	for {
+		var x, v float64
+		for v <= 0.0 {
+			x = uniformRandom.NormFloat64()
+			v = 1.0 + c * x
+		}
+		v = v * v * v
+		u := uniformRandom.Float64()
+		if u < 1.0 - 0.0331 * x * x * x * x { return d * v * scale }
+		if math.Log(u) < 0.5 * x * x + d * (1.0 - v + math.Log(v)) { return d * v * scale }
+	}
+}
+
+
+// NegativeBinomial returns a random number from the negative binomial distribution with the specified mean and dispersion k
+// (variance = mean + mean^2/k). It is generated as a Poisson whose mean is drawn from a gamma distribution with mean mean and shape k.
+func NegativeBinomial(uniformRandom *rand.Rand, mean, dispersion float64) uint32 {
+	if mean <= 0.0 { return 0 }
+	return Poisson(uniformRandom, Gamma(uniformRandom, dispersion, mean / dispersion))
+}
+
+
 // Get a random int64 from /dev/urandom to use as a seed
 func GetSeed() int64 {
 	nBig, err := crand.Int(crand.Reader, big.NewInt(math.MaxInt64))
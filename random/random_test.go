@@ -43,6 +43,63 @@ func poissonProbability(lambda float64, k uint32) float64 {
 	return math.Exp(float64(k) * math.Log(lambda) - lambda - g)
 }
 
+// Runs many iterations of generating gamma random numbers and makes sure the mean and variance
+// match shape*scale and shape*scale^2. Checks a shape < 1 (which uses a different code path) and a shape > 1.
+func TestGamma(t *testing.T) {
+	var iterations int = 100E3
+	var epsilon float64 = 0.03		// relative tolerance
+	uniformRandom := rand.New(rand.NewSource(1))
+
+	for _, params := range [][2]float64{{0.3, 0.01}, {2.5, 0.4}} {
+		shape, scale := params[0], params[1]
+		var sum, sumSq float64
+		for i := 0; i < iterations; i++ {
+			x := Gamma(uniformRandom, shape, scale)
+			if x < 0.0 { t.Fatal("Gamma returned a negative number", x, "for shape", shape, "and scale", scale) }
+			sum += x
+			sumSq += x * x
+		}
+		mean := sum / float64(iterations)
+		variance := sumSq / float64(iterations) - mean * mean
+
+		expectedMean := shape * scale
+		expectedVariance := shape * scale * scale
+		if delta := math.Abs(mean - expectedMean) / expectedMean; delta > epsilon {
+			t.Error("For shape =", shape, "and scale =", scale, "expected mean", expectedMean, "and actual mean", mean, "differ by a fraction", delta, "which is more than tolerance", epsilon)
+		}
+		if delta := math.Abs(variance - expectedVariance) / expectedVariance; delta > 2 * epsilon {
+			t.Error("For shape =", shape, "and scale =", scale, "expected variance", expectedVariance, "and actual variance", variance, "differ by a fraction", delta, "which is more than tolerance", 2 * epsilon)
+		}
+	}
+}
+
+// Runs many iterations of generating negative binomial random numbers and makes sure the mean and variance match mean and mean + mean^2/k.
+func TestNegativeBinomial(t *testing.T) {
+	var iterations int = 100E3
+	var epsilon float64 = 0.03		// relative tolerance
+	uniformRandom := rand.New(rand.NewSource(1))
+
+	for _, params := range [][2]float64{{20.0, 0.5}, {50.0, 10.0}} {
+		expectedMean, dispersion := params[0], params[1]
+		var sum, sumSq float64
+		for i := 0; i < iterations; i++ {
+			x := float64(NegativeBinomial(uniformRandom, expectedMean, dispersion))
+			sum += x
+			sumSq += x * x
+		}
+		mean := sum / float64(iterations)
+		variance := sumSq / float64(iterations) - mean * mean
+
+		expectedVariance := expectedMean + expectedMean * expectedMean / dispersion
+		if delta := math.Abs(mean - expectedMean) / expectedMean; delta > epsilon {
+			t.Error("For mean =", expectedMean, "and dispersion =", dispersion, "expected mean", expectedMean, "and actual mean", mean, "differ by a fraction", delta, "which is more than tolerance", epsilon)
+		}
+		if delta := math.Abs(variance - expectedVariance) / expectedVariance; delta > 2 * epsilon {
+			t.Error("For mean =", expectedMean, "and dispersion =", dispersion, "expected variance", expectedVariance, "and actual variance", variance, "differ by a fraction", delta, "which is more than tolerance", 2 * epsilon)
+		}
+	}
+}
+
 // Runs many iterations of shuffling a `Slice` of `int`s and computes the
 // average value found at each index. This average value should match the
 // average of all the values in the slice. As the number of iterations